@@ -0,0 +1,312 @@
+// Command fakeoms is a stand-in for the OMS API, for local development and CI.
+// It serves the endpoints the automation uses with the same JSON shapes as
+// production, driven by a scenario file:
+//
+//	go run ./cmd/fakeoms -scenario cmd/fakeoms/scenario.example.json
+//	go run . -profile local -limit 5
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"oms-automtion/models"
+	"oms-automtion/oms"
+)
+
+// fakeOMS holds the scenario and the reasons received so far.
+type fakeOMS struct {
+	mu        sync.Mutex
+	sc        *Scenario
+	errors    []ScenarioError // remaining shots are tracked in Times
+	submitted map[string][]models.ReasonPayloadItem
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "Listen address")
+	scenarioPath := flag.String("scenario", "cmd/fakeoms/scenario.example.json", "Scenario JSON file")
+	flag.Parse()
+
+	sc, err := LoadScenario(*scenarioPath)
+	if err != nil {
+		log.Fatalf("load scenario: %v", err)
+	}
+	f := &fakeOMS{
+		sc:        sc,
+		errors:    append([]ScenarioError(nil), sc.Errors...),
+		submitted: map[string][]models.ReasonPayloadItem{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth/login", f.handleLogin)
+	mux.HandleFunc("POST /reason/pending", f.authed(f.handlePending))
+	mux.HandleFunc("GET /reason/{feeder}/{outage}", f.authed(f.handleDetail))
+	mux.HandleFunc("POST /reason/outage/{id}", f.authed(f.handleSubmit))
+	mux.HandleFunc("GET /_fake/state", f.handleState)
+
+	log.Printf("fake OMS listening on %s with %d outages from %s", *addr, len(sc.Outages), *scenarioPath)
+	if err := http.ListenAndServe(*addr, logRequests(mux)); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%s)", r.Method, r.URL.Path, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// authed rejects requests that don't carry the scenario token.
+func (f *fakeOMS) authed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "bearer "+f.sc.Token {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"status": false, "message": "Unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// injectedError writes a scripted failure for endpoint/outageID, if one is
+// armed, and reports whether it did.
+func (f *fakeOMS) injectedError(w http.ResponseWriter, endpoint, outageID string) bool {
+	f.mu.Lock()
+	var hit *ScenarioError
+	for i := range f.errors {
+		e := &f.errors[i]
+		if e.Endpoint != endpoint || e.Times < 0 {
+			continue
+		}
+		if e.OutageID != "" && e.OutageID != outageID {
+			continue
+		}
+		if e.Times > 0 {
+			if e.Times--; e.Times == 0 {
+				e.Times = -1 // used up
+			}
+		}
+		hit = e
+		break
+	}
+	f.mu.Unlock()
+
+	if hit == nil {
+		return false
+	}
+	if hit.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(hit.RetryAfter))
+	}
+	body := hit.Body
+	if body == "" {
+		body = fmt.Sprintf(`{"status":false,"message":"injected %s error"}`, endpoint)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(hit.Status)
+	io.WriteString(w, body)
+	return true
+}
+
+func (f *fakeOMS) sleep(extraMs int) {
+	if d := f.sc.LatencyMs + extraMs; d > 0 {
+		time.Sleep(time.Duration(d) * time.Millisecond)
+	}
+}
+
+func (f *fakeOMS) handleLogin(w http.ResponseWriter, r *http.Request) {
+	f.sleep(0)
+	if f.injectedError(w, "login", "") {
+		return
+	}
+
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status": false, "message": "invalid body"})
+		return
+	}
+	if l := f.sc.Login; l != nil && (req.EmpNo != l.EmpNo || req.Password != l.Password) {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"status": false, "message": "Invalid credentials"})
+		return
+	}
+
+	var resp models.LoginResponse
+	resp.User.AuthToken = f.sc.Token
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (f *fakeOMS) handlePending(w http.ResponseWriter, r *http.Request) {
+	f.sleep(0)
+	if f.injectedError(w, "pending", "") {
+		return
+	}
+
+	var req models.PendingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status": false, "message": "invalid body"})
+		return
+	}
+
+	var pending []models.Outage
+	for _, o := range f.pending() {
+		if oms.MatchesFilter(o, req.FilteredData) {
+			pending = append(pending, o)
+		}
+	}
+	resp := models.PendingResponse{TotalRecords: len(pending), Data: []models.Outage{}}
+	if req.Offset >= 0 && req.Offset < len(pending) {
+		end := len(pending)
+		if req.Limit > 0 && req.Offset+req.Limit < end {
+			end = req.Offset + req.Limit
+		}
+		resp.Data = pending[req.Offset:end]
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (f *fakeOMS) handleDetail(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("outage")
+	o, ok := f.find(id)
+	if ok {
+		f.sleep(o.LatencyMs)
+	} else {
+		f.sleep(0)
+	}
+	if f.injectedError(w, "detail", id) {
+		return
+	}
+	if !ok || strconv.Itoa(o.FeederID) != r.PathValue("feeder") {
+		writeJSON(w, http.StatusNotFound, map[string]any{"status": false, "message": "Outage not found"})
+		return
+	}
+
+	var resp models.ReasonDetailResponse
+	resp.Status = true
+	resp.Message = "Success"
+	resp.Data.OutageData = o.OutageData
+	if resp.Data.OutageData == nil {
+		resp.Data.OutageData, _ = json.Marshal(o.Outage)
+	}
+	f.mu.Lock()
+	items := f.submitted[id]
+	f.mu.Unlock()
+	if len(items) > 0 {
+		resp.Data.OutageData = withRecordedReason(resp.Data.OutageData, items[0])
+	}
+	resp.Data.FeederPointGeoJson = o.feederPointGeoJson()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (f *fakeOMS) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	o, ok := f.find(id)
+	if ok {
+		f.sleep(o.LatencyMs)
+	} else {
+		f.sleep(0)
+	}
+	if f.injectedError(w, "submit", id) {
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"status": false, "message": "Outage not found"})
+		return
+	}
+
+	var items []models.ReasonPayloadItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil || len(items) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status": false, "message": "invalid body"})
+		return
+	}
+
+	if o.DropSubmit {
+		log.Printf("  reason for %s dropped (drop_submit): %+v", id, items)
+		writeJSON(w, http.StatusOK, map[string]any{"status": true, "message": "Reason updated successfully"})
+		return
+	}
+
+	f.mu.Lock()
+	_, already := f.submitted[id]
+	if !already {
+		f.submitted[id] = items
+	}
+	f.mu.Unlock()
+
+	if already {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status": false, "message": "Reason already submitted for this outage"})
+		return
+	}
+	log.Printf("  reason recorded for %s: %+v", id, items)
+	writeJSON(w, http.StatusOK, map[string]any{"status": true, "message": "Reason updated successfully"})
+}
+
+// withRecordedReason adds the reason_id and loc_id of a recorded submit to
+// outageData, which may be an object or a one-element array of objects.
+func withRecordedReason(outageData json.RawMessage, item models.ReasonPayloadItem) json.RawMessage {
+	var v any
+	if err := json.Unmarshal(outageData, &v); err != nil {
+		return outageData
+	}
+	obj, _ := v.(map[string]any)
+	if list, ok := v.([]any); ok && len(list) > 0 {
+		obj, _ = list[0].(map[string]any)
+	}
+	if obj == nil {
+		return outageData
+	}
+	obj["reason_id"] = item.ReasonID
+	obj["loc_id"] = item.LocID
+	out, _ := json.Marshal(v)
+	return out
+}
+
+// handleState reports which outages have received reasons, for tests and CI.
+func (f *fakeOMS) handleState(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	submitted := make(map[string][]models.ReasonPayloadItem, len(f.submitted))
+	for id, items := range f.submitted {
+		submitted[id] = items
+	}
+	f.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pending":   len(f.pending()),
+		"submitted": submitted,
+	})
+}
+
+// pending returns the scenario outages that haven't received a reason yet.
+func (f *fakeOMS) pending() []models.Outage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var out []models.Outage
+	for _, o := range f.sc.Outages {
+		if _, done := f.submitted[o.ID]; !done {
+			out = append(out, o.Outage)
+		}
+	}
+	return out
+}
+
+func (f *fakeOMS) find(id string) (ScenarioOutage, bool) {
+	for _, o := range f.sc.Outages {
+		if o.ID == id {
+			return o, true
+		}
+	}
+	return ScenarioOutage{}, false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
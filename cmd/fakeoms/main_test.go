@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInjectedErrorTimes(t *testing.T) {
+	f := &fakeOMS{sc: &Scenario{}, errors: []ScenarioError{
+		{Endpoint: "submit", OutageID: "OUT-1", Status: 502, Times: 1},
+		{Endpoint: "detail", Status: 503, Times: 0, RetryAfter: 2},
+	}}
+
+	// call reports the status injectedError wrote, or 0 if it let the call through.
+	call := func(endpoint, id string) int {
+		w := httptest.NewRecorder()
+		if !f.injectedError(w, endpoint, id) {
+			return 0
+		}
+		return w.Code
+	}
+
+	if got := call("submit", "OUT-2"); got != 0 {
+		t.Errorf("submit OUT-2: injected %d, want nothing (error is for OUT-1)", got)
+	}
+	if got := call("submit", "OUT-1"); got != http.StatusBadGateway {
+		t.Errorf("first submit OUT-1: got %d, want 502", got)
+	}
+	for i := range 3 {
+		if got := call("submit", "OUT-1"); got != 0 {
+			t.Errorf("submit OUT-1 #%d after times:1 used up: injected %d", i+2, got)
+		}
+	}
+
+	for i := range 5 {
+		w := httptest.NewRecorder()
+		if !f.injectedError(w, "detail", "OUT-9") || w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("detail #%d with times:0: got %d, want 503 every time", i+1, w.Code)
+		}
+		if ra := w.Header().Get("Retry-After"); ra != "2" {
+			t.Errorf("detail #%d: Retry-After = %q, want 2", i+1, ra)
+		}
+	}
+}
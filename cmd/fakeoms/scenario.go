@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"oms-automtion/models"
+)
+
+// Scenario describes what the fake OMS serves. It is loaded from a JSON
+// file; see scenario.example.json for a complete example.
+type Scenario struct {
+	// Token is handed out by /auth/login and required on every other call.
+	Token string `json:"token"`
+	// LatencyMs is added to every response.
+	LatencyMs int `json:"latency_ms"`
+	// Login, if set, restricts /auth/login to these credentials.
+	Login *ScenarioLogin `json:"login,omitempty"`
+	// Outages are served by /reason/pending until a reason is submitted.
+	Outages []ScenarioOutage `json:"outages"`
+	// Errors inject failure responses into matching calls.
+	Errors []ScenarioError `json:"errors"`
+}
+
+type ScenarioLogin struct {
+	EmpNo    string `json:"empNo"`
+	Password string `json:"password"`
+}
+
+// ScenarioOutage is a pending outage plus the detail the fake serves for it.
+type ScenarioOutage struct {
+	models.Outage
+
+	// Poles is a shortcut: each ID becomes an "HT Pole" feature in a
+	// generated feederPointGeoJson. Ignored if FeederPointGeoJson is set.
+	Poles []int `json:"poles,omitempty"`
+	// FeederPointGeoJson is served verbatim, so scenarios can reproduce the
+	// mixed array/object shapes real OMS sends.
+	FeederPointGeoJson []json.RawMessage `json:"feederPointGeoJson,omitempty"`
+	// OutageData is served verbatim as data.outageData.
+	OutageData json.RawMessage `json:"outageData,omitempty"`
+	// LatencyMs is added on top of the scenario latency for this outage.
+	LatencyMs int `json:"latency_ms,omitempty"`
+	// DropSubmit answers submits with 200 but records nothing, like an OMS
+	// that silently loses the write.
+	DropSubmit bool `json:"drop_submit,omitempty"`
+}
+
+// ScenarioError makes a call fail with Status instead of succeeding.
+type ScenarioError struct {
+	Endpoint   string `json:"endpoint"`            // "login" | "pending" | "detail" | "submit"
+	OutageID   string `json:"outage_id,omitempty"` // detail/submit only; empty matches all
+	Status     int    `json:"status"`
+	Body       string `json:"body,omitempty"`
+	Times      int    `json:"times"`                 // 0 = every matching call
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds, sent as Retry-After
+}
+
+var endpoints = map[string]bool{"login": true, "pending": true, "detail": true, "submit": true}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sc Scenario
+	if err := json.Unmarshal(raw, &sc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if sc.Token == "" {
+		sc.Token = "fake-oms-token-0123456789abcdef"
+	}
+	seen := map[string]bool{}
+	for i, o := range sc.Outages {
+		if o.ID == "" {
+			return nil, fmt.Errorf("outage #%d: id is required", i)
+		}
+		if seen[o.ID] {
+			return nil, fmt.Errorf("outage %s: duplicate id", o.ID)
+		}
+		seen[o.ID] = true
+	}
+	for i, e := range sc.Errors {
+		if !endpoints[e.Endpoint] {
+			return nil, fmt.Errorf("error #%d: unknown endpoint %q", i, e.Endpoint)
+		}
+		if e.Status < 400 || e.Status > 599 {
+			return nil, fmt.Errorf("error #%d: status %d is not an error code", i, e.Status)
+		}
+	}
+	return &sc, nil
+}
+
+// feederPointGeoJson returns the detail geometry for o, generating the usual
+// OMS layout (an array of row_to_json wrappers followed by a metadata object)
+// from o.Poles when no explicit geometry was given.
+func (o ScenarioOutage) feederPointGeoJson() []json.RawMessage {
+	if o.FeederPointGeoJson != nil {
+		return o.FeederPointGeoJson
+	}
+
+	features := make([]models.GeoFeature, 0, len(o.Poles))
+	for i, id := range o.Poles {
+		features = append(features, models.GeoFeature{
+			Type:       "Feature",
+			Geometry:   models.GeoFeatureGeometry{Type: "Point", Coordinates: []float64{72.8 + float64(i)*0.001, 21.1}},
+			LocStr:     fmt.Sprintf("%s/%d", o.FeederName, id),
+			Properties: models.GeoFeatureProperties{Hlt: "HT Pole", ID: id},
+		})
+	}
+	poles, _ := json.Marshal([]models.RowToJSONWrapper{{
+		RowToJSON: models.GeoFeatureCollection{Type: "FeatureCollection", Features: features},
+	}})
+	meta, _ := json.Marshal(map[string]any{"feeder_id": o.FeederID, "feeder_name": o.FeederName})
+	return []json.RawMessage{poles, meta}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string // substring; "" means the scenario must load
+	}{
+		{name: "minimal", json: `{"outages":[{"id":"OUT-1"}]}`},
+		{name: "not json", json: `{"outages":`, wantErr: "parse"},
+		{name: "missing id", json: `{"outages":[{"feeder_name":"X"}]}`, wantErr: "outage #0: id is required"},
+		{name: "duplicate id", json: `{"outages":[{"id":"OUT-1"},{"id":"OUT-1"}]}`, wantErr: "outage OUT-1: duplicate id"},
+		{name: "unknown endpoint", json: `{"errors":[{"endpoint":"logout","status":500}]}`, wantErr: `error #0: unknown endpoint "logout"`},
+		{name: "not an error status", json: `{"errors":[{"endpoint":"submit","status":200}]}`, wantErr: "error #0: status 200 is not an error code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			sc, err := LoadScenario(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadScenario: %v", err)
+				}
+				if sc.Token == "" {
+					t.Error("default token not set")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadScenario error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadScenarioExample(t *testing.T) {
+	if _, err := LoadScenario("scenario.example.json"); err != nil {
+		t.Fatalf("example scenario: %v", err)
+	}
+}
@@ -2,33 +2,83 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"oms-automtion/models"
 )
 
+// Endpoint settings (base URL, referer, app name, page size, pacing) live in
+// profile.go.
+
 const (
-	BaseURL  = "https://omsapi.geourja.com"
-	PageSize = 10
+	// Per-call deadline for a single OMS request (in milliseconds)
+	RequestTimeout = 30000 // 30 seconds per request, including reading the body
+
+	// Retry policy for transient OMS failures (network errors, 5xx, 429)
+	RetryMaxAttempts = 4     // total attempts per call, including the first
+	RetryBaseDelay   = 500   // ms before the first retry; doubles each time
+	RetryMaxDelay    = 10000 // ms cap on a single backoff (Retry-After may exceed it)
 
-	// Rate limiting delays (in milliseconds)
-	DelayBetweenPages   = 1000 // 1 second between pagination requests
-	DelayBetweenOutages = 1000 // 1 seconds between processing each outage
+	// Auth token cache
+	TokenRefreshMargin = 60000 // ms before expiry at which a token is renewed
+
+	// Rules file hot reload
+	RulesPollInterval = 5000 // ms between checks of the rules file's mtime
 )
 
-// Creds holds OMS login credentials. Values are read from env vars at startup
-// with fallbacks to baked-in defaults for local development.
-var Creds = struct {
-	CompanyName string
-	EmpNo       string
-	Password    string
-	AppName     string
-}{
-	CompanyName: envOr("OMS_COMPANY_NAME", "DGVCL"),
-	EmpNo:       envOr("OMS_EMP_NO", "25894"),
-	Password:    envOr("OMS_PASSWORD", "Dgvcl@8949"),
-	AppName:     envOr("OMS_APP_NAME", "SFMS-Web"),
+// TokenCachePath is where the encrypted auth token is kept between runs:
+// OMS_TOKEN_CACHE, or oms-automation/token.enc in the user cache directory.
+// "off" (or no usable cache directory) disables the cache.
+func TokenCachePath() string {
+	if v := os.Getenv("OMS_TOKEN_CACHE"); v != "" {
+		if v == "off" {
+			return ""
+		}
+		return v
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "oms-automation", "token.enc")
+}
+
+// HistoryPath is the run history file: one JSON line per run with the
+// outages it fetched and what it did with them, for `rules simulate`.
+// OMS_HISTORY_FILE, or oms-automation/history.jsonl in the user cache
+// directory; "off" disables it.
+func HistoryPath() string {
+	if v := os.Getenv("OMS_HISTORY_FILE"); v != "" {
+		if v == "off" {
+			return ""
+		}
+		return v
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "oms-automation", "history.jsonl")
+}
+
+// HistoryMaxBytes caps the run history file (OMS_HISTORY_MAX_MB, default 50).
+// When an append would pass it, the file is rotated to HistoryPath()+".1",
+// replacing the previous one, so history takes at most twice this on disk.
+// 0 or less means no cap.
+func HistoryMaxBytes() int64 {
+	return int64(envInt("OMS_HISTORY_MAX_MB", 50)) << 20
 }
 
+// TokenLifetime is assumed for tokens whose expiry can't be read from the
+// token itself (OMS_TOKEN_LIFETIME_MIN, default 60 minutes).
+func TokenLifetime() time.Duration {
+	return time.Duration(envInt("OMS_TOKEN_LIFETIME_MIN", 60)) * time.Minute
+}
+
+// OMS login credentials come from a provider; see credentials.go.
+
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -36,11 +86,33 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return fallback
+}
+
+func envFloat(key string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return f
+	}
+	return fallback
+}
+
+// DurationRules is the built-in rule set, used unless OMS_RULES_FILE / -rules
+// points at a rules file. Read the active set with Rules().
 var DurationRules = []models.DurationRule{
-	{Label: "≤ 15 min", MaxHours: 0.25, ReasonID: 21},      // Jumper Touching (0-15 minutes)
-	{Label: "15 min–1 hr", MaxHours: 1, ReasonID: 20},      // Jumper Burnt (15 min - 1 hour)
-	{Label: "1–3 hours", MaxHours: 3, ReasonID: 31},        // Tree / Tree Branch Falling (1-3 hours)
-	{Label: "3–8 hours", MaxHours: 8, ReasonID: 9},         // Conductor Snapped HT Line (3-8 hours)
-	{Label: "~15.73 hours", MaxHours: 15.73, ReasonID: 25}, // No Cause found (exactly 15.73 hours)
-	// Any other duration will be skipped
+	{Label: "≤ 15 min", MaxHours: 0.25, ReasonID: 21},                         // Jumper Touching (0-15 minutes)
+	{Label: "15 min–1 hr", MinHours: 0.25, MaxHours: 1, ReasonID: 20},         // Jumper Burnt (15 min - 1 hour)
+	{Label: "1–3 hours", MinHours: 1, MaxHours: 3, ReasonID: 31},              // Tree / Tree Branch Falling (1-3 hours)
+	{Label: "3–8 hours", MinHours: 3, MaxHours: 8, ReasonID: 9},               // Conductor Snapped HT Line (3-8 hours)
+	{Label: "~15.73 hours", Exact: ptr(15.73), Tolerance: 0.01, ReasonID: 25}, // No Cause found (exactly 15.73 hours)
+	{Label: "> 8 hours", MinHours: 8, Action: models.RuleSkip, Priority: -1},
+
+	// Site exceptions
+	{Label: "KUMBHIYA > 6h", MinHours: 6, ReasonID: 25, Priority: 10, // No Cause found
+		When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
 }
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Credentials are the OMS login details. There are no built-in defaults:
+// they must come from one of the providers below.
+type Credentials struct {
+	CompanyName string `json:"company_name"`
+	EmpNo       string `json:"emp_no"`
+	Password    string `json:"password"`
+}
+
+// CredentialProvider loads OMS credentials from one source.
+type CredentialProvider interface {
+	Name() string
+	Load() (Credentials, error)
+}
+
+// CredentialSaver is implemented by providers that can persist a rotated
+// password.
+type CredentialSaver interface {
+	Save(Credentials) error
+}
+
+// Provider names for OMS_CREDENTIALS.
+const (
+	CredsEnv       = "env"
+	CredsFile      = "file"
+	CredsEncrypted = "encrypted"
+)
+
+// EnvCredentials reads OMS_COMPANY_NAME, OMS_EMP_NO and OMS_PASSWORD.
+type EnvCredentials struct{}
+
+func (EnvCredentials) Name() string { return CredsEnv }
+
+func (EnvCredentials) Load() (Credentials, error) {
+	return Credentials{
+		CompanyName: os.Getenv("OMS_COMPANY_NAME"),
+		EmpNo:       os.Getenv("OMS_EMP_NO"),
+		Password:    os.Getenv("OMS_PASSWORD"),
+	}, nil
+}
+
+// FileCredentials reads a JSON credentials file, typically a read-only
+// Docker or Kubernetes secret mount such as /run/secrets/oms_credentials.
+type FileCredentials struct {
+	Path string
+}
+
+func (p FileCredentials) Name() string { return CredsFile + " " + p.Path }
+
+func (p FileCredentials) Load() (Credentials, error) {
+	var c Credentials
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return c, fmt.Errorf("read credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("parse credentials %s: %w", p.Path, err)
+	}
+	return c, nil
+}
+
+// EncryptedCredentials reads a credentials file sealed with AES-256-GCM
+// under a key derived from Passphrase (PBKDF2-SHA256). Rotated passwords
+// are written back to the same file.
+type EncryptedCredentials struct {
+	Path       string
+	Passphrase string
+}
+
+// sealedCredentials is the on-disk form of an encrypted credentials file.
+type sealedCredentials struct {
+	Version    int    `json:"v"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const credsKDFIterations = 600000
+
+func (p EncryptedCredentials) Name() string { return CredsEncrypted + " " + p.Path }
+
+func (p EncryptedCredentials) Load() (Credentials, error) {
+	var c Credentials
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return c, fmt.Errorf("read credentials: %w", err)
+	}
+	plain, err := Open(data, p.Passphrase)
+	if err != nil {
+		return c, fmt.Errorf("decrypt credentials %s: %w", p.Path, err)
+	}
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return c, fmt.Errorf("parse credentials %s: %w", p.Path, err)
+	}
+	return c, nil
+}
+
+func (p EncryptedCredentials) Save(c Credentials) error {
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	sealed, err := Seal(plain, p.Passphrase)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.Path, sealed, 0o600)
+}
+
+// Seal encrypts plain with a key derived from passphrase and returns the
+// JSON envelope that Open reverses.
+func Seal(plain []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	s := sealedCredentials{Version: 1, KDF: "pbkdf2-sha256", Iterations: credsKDFIterations}
+	s.Salt = make([]byte, 16)
+	if _, err := rand.Read(s.Salt); err != nil {
+		return nil, err
+	}
+	gcm, err := credsCipher(passphrase, s.Salt, s.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	s.Ciphertext = gcm.Seal(nil, s.Nonce, plain, nil)
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Open decrypts an envelope produced by Seal.
+func Open(sealed []byte, passphrase string) ([]byte, error) {
+	var s sealedCredentials
+	if err := json.Unmarshal(sealed, &s); err != nil {
+		return nil, fmt.Errorf("not an encrypted credentials file: %w", err)
+	}
+	if s.Version != 1 || s.KDF != "pbkdf2-sha256" || s.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported envelope (v=%d kdf=%q)", s.Version, s.KDF)
+	}
+	gcm, err := credsCipher(passphrase, s.Salt, s.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, s.Nonce, s.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted file")
+	}
+	return plain, nil
+}
+
+func credsCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic replaces path with data via a temp file in the same
+// directory, so a crash never leaves a half-written file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CredentialProviderFromEnv picks the provider named by OMS_CREDENTIALS
+// (env, file or encrypted). Without it, an encrypted file is used if
+// OMS_CREDENTIALS_ENCRYPTED_FILE is set, a plain file if
+// OMS_CREDENTIALS_FILE is set, and the environment otherwise.
+func CredentialProviderFromEnv() (CredentialProvider, error) {
+	file := os.Getenv("OMS_CREDENTIALS_FILE")
+	sealed := os.Getenv("OMS_CREDENTIALS_ENCRYPTED_FILE")
+
+	kind := strings.ToLower(os.Getenv("OMS_CREDENTIALS"))
+	if kind == "" {
+		switch {
+		case sealed != "":
+			kind = CredsEncrypted
+		case file != "":
+			kind = CredsFile
+		default:
+			kind = CredsEnv
+		}
+	}
+
+	switch kind {
+	case CredsEnv:
+		return EnvCredentials{}, nil
+	case CredsFile:
+		if file == "" {
+			return nil, errors.New("OMS_CREDENTIALS=file needs OMS_CREDENTIALS_FILE")
+		}
+		return FileCredentials{Path: file}, nil
+	case CredsEncrypted:
+		if sealed == "" {
+			return nil, errors.New("OMS_CREDENTIALS=encrypted needs OMS_CREDENTIALS_ENCRYPTED_FILE")
+		}
+		pass := os.Getenv("OMS_CREDENTIALS_PASSPHRASE")
+		if pass == "" {
+			return nil, errors.New("encrypted credentials need OMS_CREDENTIALS_PASSPHRASE")
+		}
+		return EncryptedCredentials{Path: sealed, Passphrase: pass}, nil
+	}
+	return nil, fmt.Errorf("unknown OMS_CREDENTIALS %q (want env, file or encrypted)", kind)
+}
+
+var (
+	credsMu       sync.RWMutex
+	creds         Credentials
+	credsProvider CredentialProvider
+)
+
+// LoadCredentials loads the credentials from p and makes them current.
+// Every field must be set; there is nothing to fall back to.
+func LoadCredentials(p CredentialProvider) error {
+	c, err := p.Load()
+	if err != nil {
+		return err
+	}
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("credentials from %s: %w", p.Name(), err)
+	}
+
+	credsMu.Lock()
+	creds, credsProvider = c, p
+	credsMu.Unlock()
+	return nil
+}
+
+func (c Credentials) validate() error {
+	var missing []string
+	if strings.TrimSpace(c.CompanyName) == "" {
+		missing = append(missing, "company name")
+	}
+	if strings.TrimSpace(c.EmpNo) == "" {
+		missing = append(missing, "employee number")
+	}
+	if c.Password == "" {
+		missing = append(missing, "password")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Creds returns the current OMS credentials; they are empty until
+// LoadCredentials succeeds.
+func Creds() Credentials {
+	credsMu.RLock()
+	defer credsMu.RUnlock()
+	return creds
+}
+
+// CredentialSource names the provider the current credentials came from.
+func CredentialSource() string {
+	credsMu.RLock()
+	defer credsMu.RUnlock()
+	if credsProvider == nil {
+		return "none"
+	}
+	return credsProvider.Name()
+}
+
+// RotatePassword makes password current. Providers that can save (the
+// encrypted file) persist it; for the others the change lasts until restart,
+// which the returned persisted flag reports.
+func RotatePassword(password string) (persisted bool, err error) {
+	if password == "" {
+		return false, errors.New("empty password")
+	}
+
+	credsMu.Lock()
+	defer credsMu.Unlock()
+
+	if credsProvider == nil {
+		return false, errors.New("no credentials loaded")
+	}
+	next := creds
+	next.Password = password
+	if saver, ok := credsProvider.(CredentialSaver); ok {
+		if err := saver.Save(next); err != nil {
+			return false, fmt.Errorf("save credentials: %w", err)
+		}
+		persisted = true
+	}
+	creds = next
+	return persisted, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	plain := []byte(`{"emp_no":"1","password":"s3cret"}`)
+	sealed, err := Seal(plain, "right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("s3cret")) {
+		t.Fatal("sealed envelope contains the plaintext")
+	}
+
+	got, err := Open(sealed, "right")
+	if err != nil {
+		t.Fatalf("Open with the right passphrase: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("round trip = %s, want %s", got, plain)
+	}
+
+	if _, err := Open(sealed, "wrong"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Open with a wrong passphrase: err = %v, want wrong passphrase", err)
+	}
+
+	var env sealedCredentials
+	if err := json.Unmarshal(sealed, &env); err != nil {
+		t.Fatal(err)
+	}
+	env.Ciphertext[0] ^= 1
+	tampered, _ := json.Marshal(env)
+	if _, err := Open(tampered, "right"); err == nil {
+		t.Error("Open accepted a tampered ciphertext")
+	}
+
+	if _, err := Open([]byte(`{"emp_no":"1"}`), "right"); err == nil {
+		t.Error("Open accepted a plain credentials file")
+	}
+	if _, err := Seal(plain, ""); err == nil {
+		t.Error("Seal accepted an empty passphrase")
+	}
+}
+
+func TestEncryptedCredentialsSaveLoad(t *testing.T) {
+	p := EncryptedCredentials{Path: filepath.Join(t.TempDir(), "creds.enc"), Passphrase: "pp"}
+	want := Credentials{CompanyName: "DGVCL", EmpNo: "1", Password: "rotated"}
+	if err := p.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
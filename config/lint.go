@@ -0,0 +1,315 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"oms-automtion/models"
+	"oms-automtion/utils"
+)
+
+// Lint severities. Rule sets with errors are rejected; warnings are shown
+// but allowed.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintFinding is one problem LintRules found in a rule set.
+type LintFinding struct {
+	Severity string   `json:"severity"`
+	Kind     string   `json:"kind"`            // invalid, unknown_reason, shadowed, conflict, overlap or gap
+	Rules    []string `json:"rules,omitempty"` // labels of the rules involved
+	Message  string   `json:"message"`
+}
+
+// LintRules checks a rule set as a whole. Errors: invalid rules, unknown
+// reason IDs, rules that can never match because a higher-priority (or
+// earlier) rule covers all their outages, and feeder-specific rules of equal
+// priority that decide the same outages differently. Warnings: rules of
+// equal priority whose ranges and conditions overlap where only their order
+// picks the winner (the earlier rule isn't narrower), and durations no
+// unconditional rule covers.
+func LintRules(rules []models.DurationRule) []LintFinding {
+	var out []LintFinding
+	add := func(sev, kind, msg string, labels ...string) {
+		out = append(out, LintFinding{Severity: sev, Kind: kind, Rules: labels, Message: msg})
+	}
+
+	var ok []int // indexes of rules valid enough to compare
+	for i, r := range rules {
+		name := strconv.Quote(r.Label)
+		if strings.TrimSpace(r.Label) == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := validateRule(r); err != nil {
+			add(LintError, "invalid", fmt.Sprintf("duration rule %s: %v", name, err), r.Label)
+			continue
+		}
+		ok = append(ok, i)
+		if _, known := LookupReason(r.ReasonID); !known && (r.ReasonID != 0 || utils.RuleAction(r) == models.RuleSubmit) {
+			add(LintError, "unknown_reason", fmt.Sprintf("duration rule %s uses unknown reason_id %d", name, r.ReasonID), r.Label)
+		}
+	}
+
+	shadowed := map[int]bool{}
+	for _, i := range ok {
+		r := rules[i]
+		for _, j := range ok {
+			s := rules[j]
+			if !beats(j, s, i, r) || !ruleSpan(s).contains(ruleSpan(r)) || !generalizes(s.When, r.When) {
+				continue
+			}
+			add(LintError, "shadowed", fmt.Sprintf("duration rule %q can never match: %q wins for every outage it covers", r.Label, s.Label), r.Label, s.Label)
+			shadowed[i] = true
+			break
+		}
+	}
+
+	for x, i := range ok {
+		for _, j := range ok[x+1:] {
+			a, b := rules[i], rules[j]
+			if shadowed[i] || shadowed[j] || !compatible(a.When, b.When) {
+				continue
+			}
+			both, overlap := ruleSpan(a).intersect(ruleSpan(b))
+			if !overlap {
+				continue
+			}
+			if feeder := sharedFeeder(a.When, b.When); feeder != "" && a.Priority == b.Priority && decision(a) != decision(b) {
+				add(LintError, "conflict", fmt.Sprintf("duration rules %q and %q both apply to feeder %s for %s but decide differently; give one a higher priority",
+					a.Label, b.Label, feeder, both), a.Label, b.Label)
+				continue
+			}
+			// A higher priority, or an earlier rule with narrower conditions,
+			// is a deliberate choice; only warn when order alone decides.
+			if a.Priority != b.Priority || narrower(a.When, b.When) {
+				continue
+			}
+			add(LintWarning, "overlap", fmt.Sprintf("duration rules %q and %q overlap on %s; %q wins there because it comes first",
+				a.Label, b.Label, both, a.Label), a.Label, b.Label)
+		}
+	}
+
+	var global []span
+	for _, i := range ok {
+		if rules[i].When == nil {
+			global = append(global, ruleSpan(rules[i]))
+		}
+	}
+	for _, g := range gaps(global) {
+		add(LintWarning, "gap", fmt.Sprintf("no unconditional rule covers %s; those outages are skipped", g))
+	}
+	return out
+}
+
+// beats reports whether rule s (at index j) is tried before rule r (at i).
+func beats(j int, s models.DurationRule, i int, r models.DurationRule) bool {
+	return s.Priority > r.Priority || s.Priority == r.Priority && j < i
+}
+
+// decision is what a rule does, for comparing rules.
+func decision(r models.DurationRule) string {
+	if a := utils.RuleAction(r); a != models.RuleSubmit {
+		return a
+	}
+	return strconv.Itoa(r.ReasonID)
+}
+
+// span is a duration range in hours; hi may be +Inf.
+type span struct {
+	lo, hi     float64
+	loIn, hiIn bool
+}
+
+func ruleSpan(r models.DurationRule) span {
+	if r.Exact != nil {
+		return span{*r.Exact - r.Tolerance, *r.Exact + r.Tolerance, true, true}
+	}
+	s := span{lo: r.MinHours, loIn: r.MinInclusive || r.MinHours == 0, hi: math.Inf(1)}
+	if r.MaxHours > 0 {
+		s.hi, s.hiIn = r.MaxHours, !r.MaxExclusive
+	}
+	return s
+}
+
+func (a span) contains(b span) bool {
+	return (a.lo < b.lo || a.lo == b.lo && (a.loIn || !b.loIn)) &&
+		(a.hi > b.hi || a.hi == b.hi && (a.hiIn || !b.hiIn))
+}
+
+func (a span) intersect(b span) (span, bool) {
+	x := a
+	if b.lo > x.lo || b.lo == x.lo && !b.loIn {
+		x.lo, x.loIn = b.lo, b.loIn
+	}
+	if b.hi < x.hi || b.hi == x.hi && !b.hiIn {
+		x.hi, x.hiIn = b.hi, b.hiIn
+	}
+	return x, x.lo < x.hi || x.lo == x.hi && x.loIn && x.hiIn
+}
+
+func (a span) String() string {
+	if a.lo == a.hi {
+		return fmt.Sprintf("%gh", a.lo)
+	}
+	lo, hi := "(", ")"
+	if a.loIn {
+		lo = "["
+	}
+	if a.hiIn {
+		hi = "]"
+	}
+	max := "∞"
+	if !math.IsInf(a.hi, 1) {
+		max = fmt.Sprintf("%gh", a.hi)
+	}
+	return fmt.Sprintf("%s%gh, %s%s", lo, a.lo, max, hi)
+}
+
+// gaps returns the parts of [0h, ∞) that none of spans covers.
+func gaps(spans []span) []span {
+	spans = slices.Clone(spans)
+	slices.SortFunc(spans, func(a, b span) int {
+		if c := cmp.Compare(a.lo, b.lo); c != 0 {
+			return c
+		}
+		switch {
+		case a.loIn == b.loIn:
+			return 0
+		case a.loIn:
+			return -1
+		}
+		return 1
+	})
+
+	var out []span
+	end, endIn := 0.0, false // everything below end (and end itself if endIn) is covered
+	for _, s := range spans {
+		if s.lo > end || s.lo == end && !endIn && !s.loIn {
+			out = append(out, span{lo: end, loIn: !endIn, hi: s.lo, hiIn: !s.loIn})
+		}
+		if s.hi > end || s.hi == end && s.hiIn {
+			end, endIn = s.hi, s.hiIn
+		}
+	}
+	if !math.IsInf(end, 1) {
+		out = append(out, span{lo: end, loIn: !endIn, hi: math.Inf(1)})
+	}
+	return out
+}
+
+// matchFields returns m's non-empty conditions by JSON name, normalised for
+// comparison: text upper-cased, weekdays as three-letter names.
+func matchFields(m *models.RuleMatch) map[string][]string {
+	f := map[string][]string{}
+	if m == nil {
+		return f
+	}
+	text := func(name string, vs []string) {
+		for _, v := range vs {
+			f[name] = append(f[name], strings.ToUpper(strings.TrimSpace(v)))
+		}
+	}
+	nums := func(name string, vs []int) {
+		for _, v := range vs {
+			f[name] = append(f[name], strconv.Itoa(v))
+		}
+	}
+	text("feeder", m.Feeder)
+	text("feeder_category", m.FeederCategory)
+	text("outage_type", m.OutageType)
+	text("interruption_type", m.InterruptionType)
+	text("substation", m.Substation)
+	text("circle", m.Circle)
+	text("division", m.Division)
+	text("subdivision", m.Subdivision)
+	nums("hour", m.Hour)
+	nums("month", m.Month)
+	for _, d := range m.Weekday {
+		if wd, ok := utils.ParseWeekday(d); ok {
+			f["weekday"] = append(f["weekday"], wd.String()[:3])
+		}
+	}
+	return f
+}
+
+// sameValue reports whether condition values v and w of field name can
+// match the same outage; feeders may be patterns.
+func sameValue(name, v, w string) bool {
+	if v == w {
+		return true
+	}
+	if name != "feeder" {
+		return false
+	}
+	m1, _ := path.Match(v, w)
+	m2, _ := path.Match(w, v)
+	return m1 || m2
+}
+
+// generalizes reports whether every outage matching r also matches s.
+func generalizes(s, r *models.RuleMatch) bool {
+	sf, rf := matchFields(s), matchFields(r)
+	for name, want := range sf {
+		got := rf[name]
+		if len(got) == 0 {
+			return false
+		}
+		for _, v := range got {
+			if !slices.ContainsFunc(want, func(w string) bool {
+				ok := w == v
+				if name == "feeder" && !ok {
+					ok, _ = path.Match(w, v)
+				}
+				return ok
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// narrower reports whether r matches a strict subset of the outages s does.
+func narrower(r, s *models.RuleMatch) bool {
+	return generalizes(s, r) && !generalizes(r, s)
+}
+
+// compatible reports whether some outage could match both a and b.
+func compatible(a, b *models.RuleMatch) bool {
+	af, bf := matchFields(a), matchFields(b)
+	for name, av := range af {
+		bv, ok := bf[name]
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(av, func(v string) bool {
+			return slices.ContainsFunc(bv, func(w string) bool { return sameValue(name, v, w) })
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// sharedFeeder returns a feeder both a and b name, or "".
+func sharedFeeder(a, b *models.RuleMatch) string {
+	if a == nil || b == nil {
+		return ""
+	}
+	for _, v := range a.Feeder {
+		for _, w := range b.Feeder {
+			v, w := strings.ToUpper(strings.TrimSpace(v)), strings.ToUpper(strings.TrimSpace(w))
+			if sameValue("feeder", v, w) {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"oms-automtion/models"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []models.DurationRule
+		want  []LintFinding
+	}{
+		{
+			name: "clean",
+			rules: []models.DurationRule{
+				{Label: "short", MaxHours: 1, MaxExclusive: true, ReasonID: 21},
+				{Label: "long", MinHours: 1, MinInclusive: true, ReasonID: 20},
+			},
+		},
+		{
+			name: "shadowed",
+			rules: []models.DurationRule{
+				{Label: "all", ReasonID: 21},
+				{Label: "short", MaxHours: 1, ReasonID: 20},
+			},
+			want: []LintFinding{
+				{Severity: LintError, Kind: "shadowed", Rules: []string{"short", "all"},
+					Message: `duration rule "short" can never match: "all" wins for every outage it covers`},
+			},
+		},
+		{
+			name: "conflict",
+			rules: []models.DurationRule{
+				{Label: "all", ReasonID: 21},
+				{Label: "K short", MaxHours: 2, ReasonID: 20, Priority: 5, When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
+				{Label: "K* long", MinHours: 1, ReasonID: 25, Priority: 5, When: &models.RuleMatch{Feeder: []string{"KUM*"}}},
+			},
+			want: []LintFinding{
+				{Severity: LintError, Kind: "conflict", Rules: []string{"K short", "K* long"},
+					Message: `duration rules "K short" and "K* long" both apply to feeder KUMBHIYA for (1h, 2h] but decide differently; give one a higher priority`},
+			},
+		},
+		{
+			name: "overlap",
+			rules: []models.DurationRule{
+				{Label: "a", MaxHours: 2, ReasonID: 21},
+				{Label: "b", MinHours: 1, MaxHours: 2.5, ReasonID: 20},
+				{Label: "K", MinHours: 1, MaxHours: 3, ReasonID: 31, When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
+				{Label: "night", MinHours: 1, MaxHours: 3, ReasonID: 31, Priority: 1, When: &models.RuleMatch{Hour: []int{0, 1}}},
+				{Label: "rest", MinHours: 2.5, ReasonID: 20},
+			},
+			want: []LintFinding{
+				{Severity: LintWarning, Kind: "overlap", Rules: []string{"a", "b"},
+					Message: `duration rules "a" and "b" overlap on (1h, 2h]; "a" wins there because it comes first`},
+				{Severity: LintWarning, Kind: "overlap", Rules: []string{"a", "K"},
+					Message: `duration rules "a" and "K" overlap on (1h, 2h]; "a" wins there because it comes first`},
+				{Severity: LintWarning, Kind: "overlap", Rules: []string{"b", "K"},
+					Message: `duration rules "b" and "K" overlap on (1h, 2.5h]; "b" wins there because it comes first`},
+			},
+		},
+		{
+			name: "decided by priority or narrower conditions",
+			rules: []models.DurationRule{
+				{Label: "K", MinHours: 1, MaxHours: 3, ReasonID: 31, When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
+				{Label: "a", MaxHours: 2, ReasonID: 21},
+				{Label: "b", MinHours: 2, ReasonID: 20},
+				{Label: "night", MinHours: 1, MaxHours: 3, ReasonID: 31, Priority: 1, When: &models.RuleMatch{Hour: []int{0, 1}}},
+			},
+		},
+		{
+			name: "gap",
+			rules: []models.DurationRule{
+				{Label: "short", MaxHours: 1, MaxExclusive: true, ReasonID: 21},
+				{Label: "mid", MinHours: 2, MaxHours: 3, ReasonID: 20},
+				{Label: "feeder", MinHours: 1, MaxHours: 2, ReasonID: 31, When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
+			},
+			want: []LintFinding{
+				{Severity: LintWarning, Kind: "gap", Message: "no unconditional rule covers [1h, 2h]; those outages are skipped"},
+				{Severity: LintWarning, Kind: "gap", Message: "no unconditional rule covers (3h, ∞); those outages are skipped"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LintRules(tt.rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintRules:\n got  %+v\n want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinRulesLintClean(t *testing.T) {
+	if got := LintRules(DurationRules); len(got) != 0 {
+		t.Errorf("built-in DurationRules have lint findings: %+v", got)
+	}
+}
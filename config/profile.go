@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Profile bundles everything that differs between OMS environments.
+type Profile struct {
+	Name     string `json:"name"`
+	BaseURL  string `json:"base_url"`
+	Referer  string `json:"referer"`
+	AppName  string `json:"app_name"`
+	PageSize int    `json:"page_size"`
+
+	// Rate limiting: every OMS request (pages, details, submits, logins,
+	// retries) draws from one token bucket. Keep these within the limits
+	// agreed with OMS.
+	RateRPS   float64 `json:"rate_rps"`   // sustained requests per second
+	RateBurst int     `json:"rate_burst"` // requests allowed back to back
+}
+
+// DefaultProfile is used when neither -profile nor OMS_PROFILE is set.
+const DefaultProfile = "production"
+
+// Profiles are the built-in OMS environments. Any field can be overridden
+// with OMS_BASE_URL, OMS_REFERER, OMS_APP_NAME, OMS_PAGE_SIZE, OMS_RATE_RPS
+// and OMS_RATE_BURST, so a new environment doesn't need a rebuild.
+var Profiles = map[string]Profile{
+	"production": {
+		BaseURL:   "https://omsapi.geourja.com",
+		Referer:   "https://smartoms.geourja.com/",
+		AppName:   "SFMS-Web",
+		PageSize:  10,
+		RateRPS:   1,
+		RateBurst: 1,
+	},
+	// training has no fixed address; set OMS_BASE_URL (and OMS_REFERER if
+	// the training front-end lives elsewhere).
+	"training": {
+		Referer:   "https://smartoms.geourja.com/",
+		AppName:   "SFMS-Web",
+		PageSize:  10,
+		RateRPS:   1,
+		RateBurst: 1,
+	},
+	// local targets cmd/fakeoms on its default port, with no need to pace.
+	"local": {
+		BaseURL:   "http://localhost:8090",
+		Referer:   "http://localhost:8090/",
+		AppName:   "SFMS-Web",
+		PageSize:  10,
+		RateRPS:   20,
+		RateBurst: 5,
+	},
+}
+
+// active starts as the plain production profile; main replaces it through
+// SelectProfile before any client is created.
+var (
+	activeMu sync.RWMutex
+	active   = withName(DefaultProfile)
+)
+
+// ProfileName returns the profile requested via OMS_PROFILE, or DefaultProfile.
+func ProfileName() string {
+	return envOr("OMS_PROFILE", DefaultProfile)
+}
+
+// SelectProfile resolves the named profile, applies env overrides and makes
+// it the active one for clients created afterwards.
+func SelectProfile(name string) (Profile, error) {
+	p, err := resolveProfile(name)
+	if err != nil {
+		return Profile{}, err
+	}
+	activeMu.Lock()
+	active = p
+	activeMu.Unlock()
+	return p, nil
+}
+
+// ActiveProfile returns the profile selected at startup.
+func ActiveProfile() Profile {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return active
+}
+
+func resolveProfile(name string) (Profile, error) {
+	p, ok := Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(Profiles))
+		for n := range Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("unknown OMS profile %q (have: %s)", name, strings.Join(names, ", "))
+	}
+	p.Name = name
+
+	p.BaseURL = strings.TrimRight(envOr("OMS_BASE_URL", p.BaseURL), "/")
+	p.Referer = envOr("OMS_REFERER", p.Referer)
+	p.AppName = envOr("OMS_APP_NAME", p.AppName)
+	p.PageSize = envInt("OMS_PAGE_SIZE", p.PageSize)
+	p.RateRPS = envFloat("OMS_RATE_RPS", p.RateRPS)
+	p.RateBurst = envInt("OMS_RATE_BURST", p.RateBurst)
+
+	switch {
+	case p.BaseURL == "":
+		return Profile{}, fmt.Errorf("OMS profile %q has no base URL; set OMS_BASE_URL", name)
+	case !strings.HasPrefix(p.BaseURL, "http://") && !strings.HasPrefix(p.BaseURL, "https://"):
+		return Profile{}, fmt.Errorf("OMS profile %q: base URL %q must start with http:// or https://", name, p.BaseURL)
+	case p.PageSize <= 0:
+		return Profile{}, fmt.Errorf("OMS profile %q: page size must be positive", name)
+	}
+	return p, nil
+}
+
+func withName(name string) Profile {
+	p := Profiles[name]
+	p.Name = name
+	return p
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"oms-automtion/models"
+)
+
+// reasonsJSON is the catalog of reason IDs OMS accepts. Set OMS_REASONS_FILE
+// to load a different copy without rebuilding.
+//
+//go:embed reasons.json
+var reasonsJSON []byte
+
+var (
+	reasonsMu sync.RWMutex
+	reasons   = map[int]models.Reason{}
+)
+
+func init() {
+	if err := LoadReasons(""); err != nil {
+		panic("embedded reasons.json: " + err.Error())
+	}
+}
+
+// LoadReasons replaces the catalog with the one at path, or with the
+// embedded catalog if path is empty.
+func LoadReasons(path string) error {
+	data := reasonsJSON
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("read reasons: %w", err)
+		}
+	}
+
+	var list []models.Reason
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse reasons: %w", err)
+	}
+	byID := make(map[int]models.Reason, len(list))
+	for _, r := range list {
+		if r.ID <= 0 || r.Name == "" {
+			return fmt.Errorf("reason %+v: id and name are required", r)
+		}
+		if _, dup := byID[r.ID]; dup {
+			return fmt.Errorf("reason %d listed twice", r.ID)
+		}
+		byID[r.ID] = r
+	}
+
+	reasonsMu.Lock()
+	reasons = byID
+	reasonsMu.Unlock()
+	return nil
+}
+
+// LookupReason returns the catalog entry for id.
+func LookupReason(id int) (models.Reason, bool) {
+	reasonsMu.RLock()
+	defer reasonsMu.RUnlock()
+	r, ok := reasons[id]
+	return r, ok
+}
+
+// ReasonName returns the English name for id, or "" if it isn't in the catalog.
+func ReasonName(id int) string {
+	r, _ := LookupReason(id)
+	return r.Name
+}
+
+// Reasons returns the whole catalog sorted by ID.
+func Reasons() []models.Reason {
+	reasonsMu.RLock()
+	defer reasonsMu.RUnlock()
+
+	out := make([]models.Reason, 0, len(reasons))
+	for _, r := range reasons {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
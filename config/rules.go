@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"oms-automtion/models"
+	"oms-automtion/utils"
+)
+
+// RulesFile is the on-disk form of a rule set. Only JSON is accepted:
+//
+//	{"rules": [{"label": "≤ 15 min", "max_hours": 0.25, "reason_id": 21}, ...]}
+type RulesFile struct {
+	Rules []models.DurationRule `json:"rules"`
+}
+
+// RulesStatus describes the active rule set and the last reload attempt.
+type RulesStatus struct {
+	Source   string                `json:"source"` // file path, or "built-in"
+	LoadedAt time.Time             `json:"loaded_at"`
+	Rules    []models.DurationRule `json:"rules"`
+	// Warning is set when the last reload failed; the rules above are the
+	// last good set and stay active until the file is fixed.
+	Warning string `json:"warning,omitempty"`
+}
+
+var (
+	rulesMu     sync.RWMutex
+	rulesStatus = RulesStatus{Source: "built-in", LoadedAt: time.Now(), Rules: DurationRules}
+)
+
+// Rules returns the active duration rules. Take one snapshot per run so a
+// reload can't change the rules half-way through.
+func Rules() []models.DurationRule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return rulesStatus.Rules
+}
+
+// CurrentRulesStatus reports the active rule set and any reload warning.
+func CurrentRulesStatus() RulesStatus {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return rulesStatus
+}
+
+// LoadRules reads and validates the rules file at path and makes it active.
+// If the file is unreadable or invalid, the current rules stay active, the
+// error is returned and kept as the status warning.
+func LoadRules(path string) error {
+	rules, err := ReadRules(path)
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if err != nil {
+		rulesStatus.Warning = fmt.Sprintf("%v — still using %d rules from %s loaded %s",
+			err, len(rulesStatus.Rules), rulesStatus.Source, rulesStatus.LoadedAt.Format(time.DateTime))
+		return err
+	}
+	rulesStatus = RulesStatus{Source: path, LoadedAt: time.Now(), Rules: rules}
+	return nil
+}
+
+// ReadRules reads and validates a rules file without activating it.
+func ReadRules(path string) ([]models.DurationRule, error) {
+	rules, err := DecodeRules(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateRules(rules); err != nil {
+		return nil, fmt.Errorf("rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// DecodeRules reads a rules file without checking the rules.
+func DecodeRules(path string) ([]models.DurationRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	var f RulesFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse rules %s%s: %w", path, jsonPosition(data, err), err)
+	}
+	return f.Rules, nil
+}
+
+// jsonPosition returns ":line:column" (1-based, column in characters) of the
+// spot a JSON decode error points at, or "" if it doesn't carry one.
+func jsonPosition(data []byte, err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		offset    int64
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		offset = int64(len(data)) + 1 // just past the end
+	default:
+		return ""
+	}
+	// The offset is just past the offending byte (for a type error, the
+	// last byte of the value); point at that byte.
+	before := data[:min(max(offset-1, 0), int64(len(data)))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	return fmt.Sprintf(":%d:%d", line, col)
+}
+
+// SaveRules validates rules, writes them to the rules file at path and
+// makes them active.
+func SaveRules(path string, rules []models.DurationRule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(RulesFile{Rules: rules}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("save rules: %w", err)
+	}
+	return LoadRules(path)
+}
+
+// ValidateRules checks a rule set: it must not be empty and LintRules must
+// find no errors in it. Lint warnings are allowed.
+func ValidateRules(rules []models.DurationRule) error {
+	if len(rules) == 0 {
+		return errors.New("no duration rules")
+	}
+	var errs []error
+	for _, f := range LintRules(rules) {
+		if f.Severity == LintError {
+			errs = append(errs, errors.New(f.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateRule checks one rule on its own: label, hour range, action and
+// conditions. Reason IDs are checked by LintRules.
+func validateRule(r models.DurationRule) error {
+	if strings.TrimSpace(r.Label) == "" {
+		return errors.New("no label")
+	}
+	if err := validateRange(r); err != nil {
+		return err
+	}
+	switch r.Action {
+	case "", models.RuleSubmit, models.RuleSkip, models.RuleManualReview:
+	default:
+		return fmt.Errorf("unknown action %q (want %s, %s or %s)",
+			r.Action, models.RuleSubmit, models.RuleSkip, models.RuleManualReview)
+	}
+	if r.PoleCount < 0 {
+		return fmt.Errorf("negative pole count %d", r.PoleCount)
+	}
+	return validateMatch(r.When)
+}
+
+// validateRange checks that a rule's duration range is non-empty.
+func validateRange(r models.DurationRule) error {
+	if r.Exact != nil {
+		switch {
+		case r.MinHours != 0 || r.MaxHours != 0 || r.MinInclusive || r.MaxExclusive:
+			return errors.New("exact can't be combined with min_hours / max_hours")
+		case *r.Exact < 0 || r.Tolerance < 0:
+			return errors.New("exact and tolerance can't be negative")
+		}
+		return nil
+	}
+	if r.Tolerance != 0 {
+		return errors.New("tolerance needs exact")
+	}
+	if r.MinHours < 0 || r.MaxHours < 0 {
+		return errors.New("negative hours")
+	}
+	if r.MaxHours == 0 {
+		if r.MaxExclusive {
+			return errors.New("max_exclusive needs max_hours")
+		}
+		return nil
+	}
+	if r.MaxHours < r.MinHours || r.MaxHours == r.MinHours && !(r.MinInclusive && !r.MaxExclusive) {
+		return fmt.Errorf("range %s is empty", utils.DescribeRange(r))
+	}
+	return nil
+}
+
+// validateMatch checks the values of a rule's conditions.
+func validateMatch(m *models.RuleMatch) error {
+	if m == nil {
+		return nil
+	}
+	for _, p := range m.Feeder {
+		if _, err := path.Match(p, ""); err != nil || strings.TrimSpace(p) == "" {
+			return fmt.Errorf("bad feeder pattern %q", p)
+		}
+	}
+	for _, h := range m.Hour {
+		if h < 0 || h > 23 {
+			return fmt.Errorf("hour %d is not in 0-23", h)
+		}
+	}
+	for _, mo := range m.Month {
+		if mo < 1 || mo > 12 {
+			return fmt.Errorf("month %d is not in 1-12", mo)
+		}
+	}
+	for _, d := range m.Weekday {
+		if _, ok := utils.ParseWeekday(d); !ok {
+			return fmt.Errorf("unknown weekday %q", d)
+		}
+	}
+	return nil
+}
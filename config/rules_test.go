@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRulesKeepsLastGoodRules(t *testing.T) {
+	defer func(s RulesStatus) { rulesStatus = s }(CurrentRulesStatus())
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"rules": [{"label": "all", "reason_id": 21}]}`)
+	if err := LoadRules(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bad := range []struct{ name, body string }{
+		{"syntax error", `{"rules": [{"label": "all", "reason_id": 21},]}`},
+		{"unknown field", `{"rules": [{"label": "all", "reason": 21}]}`},
+		{"invalid rule", `{"rules": [{"label": "inverted", "min_hours": 3, "max_hours": 1, "reason_id": 21}]}`},
+		{"missing file", ""},
+	} {
+		t.Run(bad.name, func(t *testing.T) {
+			if bad.body == "" {
+				os.Remove(path)
+			} else {
+				write(bad.body)
+			}
+			if err := LoadRules(path); err == nil {
+				t.Fatal("LoadRules accepted a bad file")
+			}
+			st := CurrentRulesStatus()
+			if len(st.Rules) != 1 || st.Rules[0].Label != "all" || st.Source != path {
+				t.Errorf("active rules = %+v from %s, want the last good file", st.Rules, st.Source)
+			}
+			if !strings.Contains(st.Warning, "still using 1 rules from "+path) {
+				t.Errorf("Warning = %q", st.Warning)
+			}
+		})
+	}
+
+	write(`{"rules": [{"label": "short", "max_hours": 1, "reason_id": 21}, {"label": "long", "min_hours": 1, "reason_id": 20}]}`)
+	if err := LoadRules(path); err != nil {
+		t.Fatal(err)
+	}
+	if st := CurrentRulesStatus(); len(st.Rules) != 2 || st.Warning != "" {
+		t.Errorf("after a good reload: %d rules, warning %q; want 2 rules and no warning", len(st.Rules), st.Warning)
+	}
+}
+
+func TestDecodeRulesPosition(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"trailing comma", "{\"rules\": [\n  {\"label\": \"≤ 15 min\", \"reason_id\": 21},\n]}", ":3:1: invalid character ']'"},
+		{"wrong type", "{\"rules\": [\n  {\"label\": \"≤ 1 h\", \"max_hours\": \"1\"}\n]}", ":2:37: json: cannot unmarshal string"},
+		{"truncated", "{\"rules\": [\n  {\"label\": ", ":2:13: unexpected EOF"},
+		{"yaml", "rules:\n  - label: all\n", ":1:1: invalid character 'r'"},
+		{"unknown field", `{"rules": [{"reason": 21}]}`, `: json: unknown field "reason"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.body), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := DecodeRules(path)
+			if err == nil || !strings.Contains(err.Error(), path+tt.want) {
+				t.Errorf("DecodeRules error = %v, want it to contain %q", err, path+tt.want)
+			}
+		})
+	}
+}
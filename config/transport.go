@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Transport holds the network settings of the OMS HTTP client. They come
+// from the environment; see TransportFromEnv.
+type Transport struct {
+	// Proxy is the outbound proxy URL. Empty means the standard
+	// HTTPS_PROXY / HTTP_PROXY / NO_PROXY variables apply; "direct" ignores
+	// them too.
+	Proxy string `json:"proxy,omitempty"`
+	// CAFile is a PEM bundle trusted in addition to the system roots, e.g.
+	// the root of a TLS-intercepting corporate proxy.
+	CAFile string `json:"ca_file,omitempty"`
+	// ClientCert and ClientKey are a PEM client certificate and key for
+	// mutual TLS. Both or neither must be set.
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+
+	ConnectTimeout  time.Duration `json:"connect_timeout"`  // TCP connect and TLS handshake, each
+	ResponseTimeout time.Duration `json:"response_timeout"` // until response headers arrive; 0 = only RequestTimeout
+
+	MaxIdleConns        int `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int `json:"max_conns_per_host"` // 0 = unlimited
+}
+
+// TransportFromEnv reads OMS_PROXY, OMS_CA_FILE, OMS_CLIENT_CERT,
+// OMS_CLIENT_KEY, OMS_CONNECT_TIMEOUT_MS, OMS_RESPONSE_TIMEOUT_MS,
+// OMS_MAX_IDLE_CONNS, OMS_MAX_IDLE_CONNS_PER_HOST and OMS_MAX_CONNS_PER_HOST
+// and checks them. Files are only checked for existence here; their
+// contents are parsed when the client is built.
+func TransportFromEnv() (Transport, error) {
+	t := Transport{
+		Proxy:      os.Getenv("OMS_PROXY"),
+		CAFile:     os.Getenv("OMS_CA_FILE"),
+		ClientCert: os.Getenv("OMS_CLIENT_CERT"),
+		ClientKey:  os.Getenv("OMS_CLIENT_KEY"),
+	}
+
+	var err error
+	var connectMs, responseMs int
+	for _, it := range []struct {
+		key  string
+		def  int
+		dest *int
+	}{
+		{"OMS_CONNECT_TIMEOUT_MS", 10000, &connectMs},
+		{"OMS_RESPONSE_TIMEOUT_MS", 0, &responseMs},
+		{"OMS_MAX_IDLE_CONNS", 10, &t.MaxIdleConns},
+		{"OMS_MAX_IDLE_CONNS_PER_HOST", 2, &t.MaxIdleConnsPerHost},
+		{"OMS_MAX_CONNS_PER_HOST", 0, &t.MaxConnsPerHost},
+	} {
+		if *it.dest, err = envCount(it.key, it.def); err != nil {
+			return t, err
+		}
+	}
+	t.ConnectTimeout = time.Duration(connectMs) * time.Millisecond
+	t.ResponseTimeout = time.Duration(responseMs) * time.Millisecond
+
+	return t, t.Validate()
+}
+
+// Validate checks t for settings that can't work.
+func (t Transport) Validate() error {
+	if t.Proxy != "" && t.Proxy != "direct" {
+		u, err := url.Parse(t.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("OMS proxy %q is not a URL", t.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("OMS proxy %q: scheme must be http, https or socks5", t.Proxy)
+		}
+	}
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return fmt.Errorf("OMS client certificate needs both OMS_CLIENT_CERT and OMS_CLIENT_KEY")
+	}
+	for _, f := range []string{t.CAFile, t.ClientCert, t.ClientKey} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			return fmt.Errorf("OMS TLS file: %w", err)
+		}
+	}
+	if t.ConnectTimeout <= 0 {
+		return fmt.Errorf("OMS connect timeout must be positive")
+	}
+	if t.MaxIdleConnsPerHost > t.MaxIdleConns && t.MaxIdleConns > 0 {
+		return fmt.Errorf("OMS_MAX_IDLE_CONNS_PER_HOST (%d) exceeds OMS_MAX_IDLE_CONNS (%d)", t.MaxIdleConnsPerHost, t.MaxIdleConns)
+	}
+	return nil
+}
+
+// envCount reads a non-negative integer from key, or returns fallback if
+// the variable is unset.
+func envCount(key string, fallback int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s=%q: want a non-negative integer", key, v)
+	}
+	return n, nil
+}
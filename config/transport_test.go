@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTransportFromEnv(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("pem"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "CA file", env: map[string]string{"OMS_CA_FILE": caFile}},
+		{name: "missing CA file", env: map[string]string{"OMS_CA_FILE": caFile + ".missing"}, wantErr: true},
+		{name: "cert without key", env: map[string]string{"OMS_CLIENT_CERT": caFile}, wantErr: true},
+		{name: "bad proxy scheme", env: map[string]string{"OMS_PROXY": "ftp://proxy:21"}, wantErr: true},
+		{name: "negative count", env: map[string]string{"OMS_MAX_IDLE_CONNS": "-1"}, wantErr: true},
+		{name: "zero connect timeout", env: map[string]string{"OMS_CONNECT_TIMEOUT_MS": "0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"OMS_PROXY", "OMS_CA_FILE", "OMS_CLIENT_CERT", "OMS_CLIENT_KEY",
+				"OMS_CONNECT_TIMEOUT_MS", "OMS_RESPONSE_TIMEOUT_MS", "OMS_MAX_IDLE_CONNS",
+				"OMS_MAX_IDLE_CONNS_PER_HOST", "OMS_MAX_CONNS_PER_HOST"} {
+				t.Setenv(k, tt.env[k])
+			}
+			got, err := TransportFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransportFromEnv error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (got.CAFile != tt.env["OMS_CA_FILE"] || got.ConnectTimeout != 10*time.Second) {
+				t.Errorf("TransportFromEnv = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"oms-automtion/config"
+)
+
+// runCredsCommand implements `creds`:
+//
+//	oms-automtion creds encrypt -out creds.enc < creds.json
+//	oms-automtion creds check
+//
+// encrypt seals a JSON credentials file ({"company_name", "emp_no",
+// "password"}) with OMS_CREDENTIALS_PASSPHRASE for use as
+// OMS_CREDENTIALS_ENCRYPTED_FILE. check loads the credentials the way a run
+// would and reports where they came from, without logging in.
+func runCredsCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("creds: want a subcommand: encrypt or check")
+	}
+	switch args[0] {
+	case "encrypt":
+		return credsEncrypt(args[1:])
+	case "check":
+		return loadCredentials()
+	}
+	return fmt.Errorf("creds: unknown subcommand %q (want encrypt or check)", args[0])
+}
+
+func credsEncrypt(args []string) error {
+	fs := flag.NewFlagSet("creds encrypt", flag.ExitOnError)
+	in := fs.String("in", "", "Plain JSON credentials file (default: stdin)")
+	out := fs.String("out", "", "Encrypted file to write (required)")
+	fs.Parse(args)
+
+	if *out == "" {
+		return fmt.Errorf("creds encrypt: -out is required")
+	}
+	pass := os.Getenv("OMS_CREDENTIALS_PASSPHRASE")
+	if pass == "" {
+		return fmt.Errorf("creds encrypt: set OMS_CREDENTIALS_PASSPHRASE")
+	}
+
+	var (
+		plain []byte
+		err   error
+	)
+	if *in == "" {
+		plain, err = io.ReadAll(os.Stdin)
+	} else {
+		plain, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return fmt.Errorf("creds encrypt: %w", err)
+	}
+
+	var c config.Credentials
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return fmt.Errorf("creds encrypt: parse input: %w", err)
+	}
+	p := config.EncryptedCredentials{Path: *out, Passphrase: pass}
+	if err := p.Save(c); err != nil {
+		return fmt.Errorf("creds encrypt: %w", err)
+	}
+	if err := config.LoadCredentials(p); err != nil {
+		return fmt.Errorf("creds encrypt: %w", err)
+	}
+	log.Printf("  ✓ Wrote %s for empNo=%s", *out, c.EmpNo)
+	return nil
+}
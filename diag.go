@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/oms"
+)
+
+// runDiagCommand implements `diag`: it prints the effective OMS settings
+// (profile, credentials source, proxy, TLS, timeouts, pools) and checks
+// that the OMS host can be reached through them. With -login it also tries
+// a real login.
+func runDiagCommand(args []string) error {
+	fs := flag.NewFlagSet("diag", flag.ExitOnError)
+	profile := fs.String("profile", config.ProfileName(), "OMS endpoint profile: production, training or local")
+	login := fs.Bool("login", false, "Also log in to OMS with the configured credentials")
+	fs.Parse(args)
+
+	out := os.Stdout
+	fail := 0
+	check := func(name string, err error, okDetail string) {
+		if err != nil {
+			fail++
+			fmt.Fprintf(out, "  ✗ %-14s %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(out, "  ✓ %-14s %s\n", name, okDetail)
+	}
+
+	fmt.Fprintln(out, "═══ OMS diagnostics ═══")
+
+	if _, err := config.SelectProfile(*profile); err != nil {
+		return err
+	}
+	p := config.ActiveProfile()
+	fmt.Fprintf(out, "  Profile        %s → %s (page size %d, %.1f rps, burst %d)\n",
+		p.Name, p.BaseURL, p.PageSize, p.RateRPS, p.RateBurst)
+
+	t, err := config.TransportFromEnv()
+	check("transport", err, "settings valid")
+	if err != nil {
+		return fmt.Errorf("diag: %d check(s) failed", fail)
+	}
+	hc, info, err := oms.NewHTTPClient(t)
+	check("TLS files", err, describeTLS(t, info))
+	if err != nil {
+		return fmt.Errorf("diag: %d check(s) failed", fail)
+	}
+	fmt.Fprintf(out, "  Proxy          %s\n", oms.ProxyFor(hc, p.BaseURL))
+	fmt.Fprintf(out, "  Timeouts       connect %s, response %s, request %s\n",
+		t.ConnectTimeout, orNone(t.ResponseTimeout), time.Duration(config.RequestTimeout)*time.Millisecond)
+	fmt.Fprintf(out, "  Pool           %d idle, %d idle/host, %s conns/host\n",
+		t.MaxIdleConns, t.MaxIdleConnsPerHost, orUnlimited(t.MaxConnsPerHost))
+
+	cp, err := config.CredentialProviderFromEnv()
+	if err == nil {
+		err = config.LoadCredentials(cp)
+	}
+	check("credentials", err, fmt.Sprintf("%s (empNo=%s)", config.CredentialSource(), config.Creds().EmpNo))
+	if path := config.TokenCachePath(); path != "" {
+		fmt.Fprintf(out, "  Token cache    %s\n", path)
+	} else {
+		fmt.Fprintf(out, "  Token cache    off\n")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.RequestTimeout)*time.Millisecond)
+	defer cancel()
+
+	u, err := url.Parse(p.BaseURL)
+	if err == nil {
+		var addrs []string
+		addrs, err = net.DefaultResolver.LookupHost(ctx, u.Hostname())
+		check("DNS", err, fmt.Sprintf("%s → %v", u.Hostname(), addrs))
+	}
+
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/", nil)
+	resp, err := hc.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		detail := fmt.Sprintf("HTTP %d in %s", resp.StatusCode, time.Since(start).Round(time.Millisecond))
+		if resp.TLS != nil {
+			detail += "; " + describePeer(resp.TLS)
+		}
+		check("reach OMS", nil, detail)
+	} else {
+		check("reach OMS", err, "")
+	}
+
+	if *login && config.Creds().Password != "" {
+		client := oms.NewClient()
+		client.HTTPClient = hc
+		client.TokenCache = nil
+		check("login", client.Login(ctx), "credentials accepted")
+	}
+
+	if fail > 0 {
+		return fmt.Errorf("diag: %d check(s) failed", fail)
+	}
+	fmt.Fprintln(out, "═══ All checks passed ═══")
+	return nil
+}
+
+func describeTLS(t config.Transport, info *oms.TransportInfo) string {
+	if info == nil {
+		return ""
+	}
+	s := "system roots"
+	if t.CAFile != "" {
+		s += fmt.Sprintf(" + %d from %s", info.ExtraCAs, t.CAFile)
+	}
+	if info.ClientCertSubject != "" {
+		s += fmt.Sprintf("; client cert %s (expires %s)", info.ClientCertSubject, info.ClientCertExpiry.Format(time.DateOnly))
+	}
+	return s
+}
+
+func describePeer(cs *tls.ConnectionState) string {
+	if len(cs.PeerCertificates) == 0 {
+		return tls.VersionName(cs.Version)
+	}
+	leaf := cs.PeerCertificates[0]
+	return fmt.Sprintf("%s, cert %s issued by %s (expires %s)", tls.VersionName(cs.Version),
+		leaf.Subject.CommonName, leaf.Issuer.CommonName, leaf.NotAfter.Format(time.DateOnly))
+}
+
+func orNone(d time.Duration) string {
+	if d == 0 {
+		return "none"
+	}
+	return d.String()
+}
+
+func orUnlimited(n int) string {
+	if n == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
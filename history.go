@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+)
+
+// HistoryEntry is one run in the run history file (config.HistoryPath):
+// the pending outages it fetched and what it did with each of them.
+type HistoryEntry struct {
+	StartedAt   time.Time       `json:"started_at"`
+	Profile     string          `json:"profile"`
+	Replay      bool            `json:"replay,omitempty"` // outages came from a cassette, not OMS
+	RulesSource string          `json:"rules_source"`
+	Outages     []models.Outage `json:"outages"`
+	Rows        []ProcessedRow  `json:"rows"`
+}
+
+// appendHistory adds result as one line to the history file at path. Runs
+// that fetched nothing, and an empty path, are not recorded. A file that
+// would grow past config.HistoryMaxBytes is rotated first.
+func appendHistory(path string, result *RunResult) error {
+	if path == "" || len(result.Outages) == 0 {
+		return nil
+	}
+	line, err := json.Marshal(HistoryEntry{
+		StartedAt:   result.StartedAt,
+		Profile:     result.Profile,
+		Replay:      result.Replay,
+		RulesSource: result.RulesSource,
+		Outages:     result.Outages,
+		Rows:        result.Rows,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := rotateHistory(path, int64(len(line))+1, config.HistoryMaxBytes()); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotateHistory moves the history file at path to path+".1" if adding n
+// bytes would take it past max (max <= 0: never).
+func rotateHistory(path string, n, max int64) error {
+	if max <= 0 {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() == 0 || fi.Size()+n <= max {
+		return nil
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		return fmt.Errorf("rotate run history: %w", err)
+	}
+	log.Printf("⚙ Run history passed %d MB; older runs moved to %s.1", max>>20, path)
+	return nil
+}
+
+// readHistory returns the live runs against profile in the history file at
+// path, and its rotated predecessor, that started in [since, until) (a zero
+// until means no end); other environments' queues and replayed cassettes
+// would skew a simulation. Lines that don't parse, such as one cut short by
+// a run still writing it, are skipped with a warning.
+func readHistory(path, profile string, since, until time.Time) ([]HistoryEntry, error) {
+	if path == "" {
+		return nil, fmt.Errorf("run history is off (OMS_HISTORY_FILE=off)")
+	}
+	var entries []HistoryEntry
+	for _, p := range []string{path + ".1", path} {
+		f, err := os.Open(p)
+		if errors.Is(err, fs.ErrNotExist) && p != path {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("run history: %w", err)
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 64<<20) // one line holds a whole run
+		for n := 1; sc.Scan(); n++ {
+			var e HistoryEntry
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				log.Printf("  [WARN] Run history %s line %d skipped: %v", p, n, err)
+				continue
+			}
+			if e.Profile == profile && !e.Replay && !e.StartedAt.Before(since) && (until.IsZero() || e.StartedAt.Before(until)) {
+				entries = append(entries, e)
+			}
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("run history: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+// historyRange parses the since/until dates (YYYY-MM-DD, either may be
+// empty) of a history query into [since, until) bounds; until is inclusive
+// of the whole day.
+func historyRange(since, until string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if since != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, since, time.Local); err != nil {
+			return from, to, fmt.Errorf("since: %w", err)
+		}
+	}
+	if until != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, until, time.Local); err != nil {
+			return from, to, fmt.Errorf("until: %w", err)
+		}
+		if to.Before(from) {
+			return from, to, fmt.Errorf("until %s is before since %s", until, since)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+// historyOutages flattens runs into distinct outages. An outage seen by
+// several runs appears once, as the latest run saw it.
+func historyOutages(entries []HistoryEntry) []models.Outage {
+	index := map[string]int{}
+	var out []models.Outage
+	for _, e := range entries {
+		for _, o := range e.Outages {
+			if i, ok := index[o.ID]; ok {
+				out[i] = o
+				continue
+			}
+			index[o.ID] = len(out)
+			out = append(out, o)
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+	"oms-automtion/oms"
+)
+
+func TestReadHistoryFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	runs := []struct {
+		id, profile string
+		replay      bool
+		started     time.Time
+	}{
+		{"old", "local", false, day.AddDate(0, 0, -7)},
+		{"live", "local", false, day},
+		{"training", "training", false, day},
+		{"replayed", "local", true, day},
+		{"recorded", "local", false, day},
+		{"next week", "local", false, day.AddDate(0, 0, 7)},
+	}
+	for _, r := range runs {
+		result := &RunResult{StartedAt: r.started, Profile: r.profile, Replay: r.replay, Outages: []models.Outage{{ID: r.id}}}
+		if err := appendHistory(path, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := readHistory(path, "local", day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range historyOutages(entries) {
+		got = append(got, o.ID)
+	}
+	if want := []string{"live", "recorded"}; !slices.Equal(got, want) {
+		t.Errorf("history outages = %q, want %q", got, want)
+	}
+}
+
+func TestRunAutomationKeepsReplaysOutOfHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv("OMS_HISTORY_FILE", path)
+
+	for _, replay := range []bool{true, false} {
+		api := oms.NewMemory().AddOutage(outage(fmt.Sprint("replay=", replay), 1, "10:06:00.000"), 11)
+		if _, err := RunAutomation(context.Background(), api, RunOptions{Replay: replay}, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := readHistory(path, config.ActiveProfile().Name, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range historyOutages(entries) {
+		got = append(got, o.ID)
+	}
+	if want := []string{"replay=false"}; !slices.Equal(got, want) {
+		t.Errorf("history outages = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryRange(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation(time.DateOnly, s, time.Local)
+		return d
+	}
+	tests := []struct {
+		since, until     string
+		wantFrom, wantTo time.Time
+		wantErr          bool
+	}{
+		{"", "", time.Time{}, time.Time{}, false},
+		{"2026-01-01", "", day("2026-01-01"), time.Time{}, false},
+		{"", "2026-01-31", time.Time{}, day("2026-02-01"), false},
+		{"2026-01-31", "2026-01-31", day("2026-01-31"), day("2026-02-01"), false},
+		{"2026-02-01", "2026-01-31", time.Time{}, time.Time{}, true},
+		{"01/02/2026", "", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		from, to, err := historyRange(tt.since, tt.until)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("historyRange(%q, %q) error = %v, want error %v", tt.since, tt.until, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (!from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo)) {
+			t.Errorf("historyRange(%q, %q) = %s, %s; want %s, %s", tt.since, tt.until, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
+
+func TestAppendHistoryRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv("OMS_HISTORY_MAX_MB", "1")
+	big := make([]models.Outage, 1200) // about 0.4 MB per run, so two fit in a file
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	for i := range 5 {
+		big[0].ID = fmt.Sprint("run-", i)
+		if err := appendHistory(path, &RunResult{StartedAt: day, Profile: "local", Outages: big}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1"} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 1<<20 {
+			t.Errorf("%s is %d bytes, want at most 1 MB", p, fi.Size())
+		}
+	}
+	entries, err := readHistory(path, "local", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Outages[0].ID)
+	}
+	// Runs 0 and 1 went with the first rotated file; the rest read in order.
+	if want := []string{"run-2", "run-3", "run-4"}; !slices.Equal(got, want) {
+		t.Errorf("runs in history = %q, want %q", got, want)
+	}
+}
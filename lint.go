@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+)
+
+// rulesLint implements `rules lint [-rules file | file] [-json]`: it lints a
+// rules file, or the active rules, and fails if LintRules finds errors.
+func rulesLint(args []string) error {
+	fs := flag.NewFlagSet("rules lint", flag.ExitOnError)
+	path := fs.String("rules", rulesPath, "Rules file to lint (default: OMS_RULES_FILE or the built-in rules)")
+	asJSON := fs.Bool("json", false, "Print the findings as JSON")
+	fs.Parse(args)
+
+	switch {
+	case fs.NArg() > 1:
+		return fmt.Errorf("rules lint: want at most one rules file, got %d", fs.NArg())
+	case fs.NArg() == 1:
+		*path = fs.Arg(0)
+	}
+
+	if path := os.Getenv("OMS_REASONS_FILE"); path != "" {
+		if err := config.LoadReasons(path); err != nil {
+			return err
+		}
+	}
+	rules, source := config.DurationRules, "built-in"
+	if *path != "" {
+		var err error
+		if rules, err = config.DecodeRules(*path); err != nil {
+			return err
+		}
+		source = *path
+	}
+
+	findings := config.LintRules(rules)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("═══ Rules lint: %d rules from %s ═══\n", len(rules), source)
+		printLint(os.Stdout, findings)
+	}
+	if n := countLint(findings, config.LintError); n > 0 {
+		return fmt.Errorf("rules lint: %d error(s)", n)
+	}
+	return nil
+}
+
+func printLint(out io.Writer, findings []config.LintFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(out, "  ✓ No problems found")
+		return
+	}
+	for _, f := range findings {
+		mark := "✗"
+		if f.Severity == config.LintWarning {
+			mark = "⚠"
+		}
+		fmt.Fprintf(out, "  %s %-14s %s\n", mark, f.Kind, f.Message)
+	}
+}
+
+func countLint(findings []config.LintFinding, severity string) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// logLintWarnings logs the lint warnings of rules that passed validation.
+func logLintWarnings(rules []models.DurationRule) {
+	for _, f := range config.LintRules(rules) {
+		if f.Severity == config.LintWarning {
+			log.Printf("  [WARN] Rules lint (%s): %s", f.Kind, f.Message)
+		}
+	}
+}
@@ -2,11 +2,65 @@ package models
 
 import "encoding/json"
 
-// DurationRule defines the mapping from duration to a specific ReasonID.
+// DurationRule decides what happens to an outage: which reason is
+// submitted, or whether it is skipped or left for manual review.
+//
+// A rule covers either a range, MinHours to MaxHours (MaxHours 0 = no upper
+// limit), or, if Exact is set, the durations within Tolerance of it. By
+// default the range excludes MinHours and includes MaxHours; MinInclusive and
+// MaxExclusive flip the edges. The rule applies to covered outages that meet
+// its When conditions.
 type DurationRule struct {
-	Label    string
-	MaxHours float64
-	ReasonID int
+	Label        string   `json:"label"`
+	MinHours     float64  `json:"min_hours,omitempty"`
+	MaxHours     float64  `json:"max_hours,omitempty"`
+	MinInclusive bool     `json:"min_inclusive,omitempty"`
+	MaxExclusive bool     `json:"max_exclusive,omitempty"`
+	Exact        *float64 `json:"exact,omitempty"`
+	Tolerance    float64  `json:"tolerance,omitempty"` // hours either side of Exact
+	// Action is RuleSubmit (the default), RuleSkip or RuleManualReview.
+	Action   string `json:"action,omitempty"`
+	ReasonID int    `json:"reason_id,omitempty"` // required for RuleSubmit
+	// PoleCount is how many distinct HT poles the reason is submitted on;
+	// 0 means 1. Outages on feeders with fewer poles use all of them.
+	PoleCount int `json:"pole_count,omitempty"`
+	// Priority decides between matching rules: the highest wins, and rules
+	// of equal priority are tried in order.
+	Priority int `json:"priority,omitempty"`
+	// When restricts the rule to some outages; nil matches every outage.
+	When *RuleMatch `json:"when,omitempty"`
+}
+
+// DurationRule actions.
+const (
+	RuleSubmit       = "submit"
+	RuleSkip         = "skip"
+	RuleManualReview = "manual_review"
+)
+
+// RuleMatch lists conditions on a pending outage. Every non-empty field
+// must match; a field matches if any of its values does. Text compares
+// ignore case.
+type RuleMatch struct {
+	Feeder           []string `json:"feeder,omitempty"` // feeder names; * and ? wildcards allowed
+	FeederCategory   []string `json:"feeder_category,omitempty"`
+	OutageType       []string `json:"outage_type,omitempty"` // ID or name
+	InterruptionType []string `json:"interruption_type,omitempty"`
+	Substation       []string `json:"substation,omitempty"`
+	Circle           []string `json:"circle,omitempty"`
+	Division         []string `json:"division,omitempty"`
+	Subdivision      []string `json:"subdivision,omitempty"`
+	Hour             []int    `json:"hour,omitempty"`    // hour of occurrence, 0-23
+	Weekday          []string `json:"weekday,omitempty"` // day of occurrence: mon, tue, …
+	Month            []int    `json:"month,omitempty"`   // month of occurrence, 1-12
+}
+
+// Reason is one entry of the OMS outage reason catalog.
+type Reason struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`    // English name as shown in OMS
+	NameGu   string `json:"name_gu"` // Gujarati name
+	Category string `json:"category"`
 }
 
 // ─── PENDING OUTAGES ───
@@ -21,6 +75,19 @@ type FilteredData struct {
 	ToValue   string `json:"toValue"`
 }
 
+// PendingFilter narrows /reason/pending on the server side. Empty fields
+// don't filter; From/To are inclusive occurrence dates (YYYY-MM-DD).
+type PendingFilter struct {
+	Feeder      string `json:"feeder,omitempty"`
+	Substation  string `json:"substation,omitempty"`
+	Circle      string `json:"circle,omitempty"`
+	Division    string `json:"division,omitempty"`
+	Subdivision string `json:"subdivision,omitempty"`
+	OutageType  string `json:"outage_type,omitempty"` // numeric type ID or type name
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+}
+
 type PendingRequest struct {
 	OrderBy      *string        `json:"orderBy"`
 	Order        *string        `json:"order"`
@@ -90,6 +157,59 @@ type ReasonDetailResponse struct {
 	} `json:"data"`
 }
 
+// OutageData is the typed form of data.outageData in the reason detail. It
+// repeats the pending-list fields and adds what the field staff recorded.
+// Keys this struct doesn't know about are kept in Extra.
+type OutageData struct {
+	Outage
+
+	FaultLocation      string `json:"fault_location"`
+	BreakerName        string `json:"breaker_name"`
+	TripType           string `json:"trip_type"` // e.g. "O/C", "E/F"
+	RelayIndication    string `json:"relay_indication"`
+	RestorationRemarks string `json:"restoration_remarks"`
+
+	// ReasonID and LocID are set once a reason has been recorded.
+	ReasonID int `json:"reason_id"`
+	LocID    int `json:"loc_id"`
+
+	Extra map[string]json.RawMessage `json:"extra,omitempty"`
+}
+
+// FeederMetadata is a non-geometry object found in feederPointGeoJson.
+// Keys this struct doesn't know about are kept in Extra.
+type FeederMetadata struct {
+	FeederID   int    `json:"feeder_id"`
+	FeederName string `json:"feeder_name"`
+	TotalPoles int    `json:"total_poles"`
+
+	Extra map[string]json.RawMessage `json:"extra,omitempty"`
+}
+
+// FeederFeature is one GeoJSON feature of a feeder, whatever its pole type.
+type FeederFeature struct {
+	ID           int       `json:"id"`
+	Type         string    `json:"type"` // properties.hlt, e.g. "HT Pole", "LT Pole"
+	LocStr       string    `json:"loc_str,omitempty"`
+	GeometryType string    `json:"geometry_type,omitempty"`
+	Coordinates  []float64 `json:"coordinates,omitempty"` // [lon, lat] for points
+}
+
+// FeederTopology is everything found in feederPointGeoJson. Elements the
+// parser couldn't make sense of are described in Warnings, not dropped.
+type FeederTopology struct {
+	Features []FeederFeature  `json:"features"`
+	Metadata []FeederMetadata `json:"metadata,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// OutageDetail is the decoded /reason/{feeder}/{outage} response.
+type OutageDetail struct {
+	Data     OutageData     `json:"data"`
+	Topology FeederTopology `json:"topology"`
+	PoleIDs  []int          `json:"pole_ids"` // HT poles, the valid loc_ids
+}
+
 // ─── SUBMIT REASON ───
 
 type ReasonPayloadItem struct {
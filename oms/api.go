@@ -0,0 +1,30 @@
+package oms
+
+import (
+	"context"
+	"iter"
+
+	"oms-automtion/models"
+)
+
+// API is the set of OMS operations the automation pipeline depends on.
+// *Client talks to the real service; *Memory is an in-process stand-in for
+// trying out rule changes without touching production.
+type API interface {
+	// Login establishes a session used by the other calls.
+	Login(ctx context.Context) error
+	// PendingOutages streams the outages still awaiting a reason that match
+	// filter. A failed fetch is yielded as an error and ends the sequence.
+	PendingOutages(ctx context.Context, filter models.PendingFilter) iter.Seq2[models.Outage, error]
+	// FetchOutageDetail returns the decoded outage detail, including the HT
+	// pole IDs of the outage's feeder.
+	FetchOutageDetail(ctx context.Context, outageID string, feederID int) (*models.OutageDetail, error)
+	// SubmitReasons records every loc_id/reason_id pair of items for the
+	// outage in one call.
+	SubmitReasons(ctx context.Context, outageID string, items []models.ReasonPayloadItem) error
+}
+
+var (
+	_ API = (*Client)(nil)
+	_ API = (*Memory)(nil)
+)
@@ -0,0 +1,275 @@
+package oms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassette modes accepted by Client.UseCassette.
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// Placeholders written over secrets in recorded traffic.
+const (
+	redactedToken  = "[REDACTED-AUTH-TOKEN]"
+	redactedSecret = "[REDACTED]"
+)
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Method         string      `json:"method"`
+	Path           string      `json:"path"` // path and query; the host is not recorded
+	RequestBody    string      `json:"request_body,omitempty"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body"`
+	RecordedAt     time.Time   `json:"recorded_at"`
+}
+
+// Cassette is the on-disk format written by Recorder and read by Replayer.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// UseCassette switches c to record its traffic into the cassette at path, or
+// to replay a previously recorded cassette offline. Replay also disables the
+// rate limiter and retry delays, since no real server is involved.
+func (c *Client) UseCassette(mode, path string) error {
+	// Cassettes must see (and replay) the login, never a cached token.
+	c.TokenCache = nil
+	switch mode {
+	case CassetteRecord:
+		c.HTTPClient = &http.Client{Transport: NewRecorder(path, transportOf(c.HTTPClient))}
+	case CassetteReplay:
+		r, err := LoadReplayer(path)
+		if err != nil {
+			return err
+		}
+		c.HTTPClient = &http.Client{Transport: r}
+		c.Limiter = nil
+		c.Retry.BaseDelay, c.Retry.MaxDelay = 0, 0
+	default:
+		return fmt.Errorf("unknown cassette mode %q (want %q or %q)", mode, CassetteRecord, CassetteReplay)
+	}
+	return nil
+}
+
+func transportOf(hc *http.Client) http.RoundTripper {
+	if hc != nil && hc.Transport != nil {
+		return hc.Transport
+	}
+	return http.DefaultTransport
+}
+
+// Recorder is an http.RoundTripper that forwards to Base and appends every
+// exchange to a cassette file. Passwords and auth tokens are redacted before
+// anything touches disk. Each exchange is appended as it happens and the file
+// stays valid JSON throughout, so a cancelled or crashed run still leaves a
+// usable cassette.
+type Recorder struct {
+	Base http.RoundTripper
+
+	mu    sync.Mutex
+	path  string
+	n     int    // interactions written so far
+	token string // last token seen in a login response
+}
+
+// NewRecorder returns a Recorder writing a fresh cassette to path.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	return &Recorder{Base: base, path: path}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tok := loginToken(respBody); tok != "" {
+		r.token = tok
+	}
+
+	err = r.save(Interaction{
+		Method:         req.Method,
+		Path:           req.URL.RequestURI(),
+		RequestBody:    r.redact(reqBody),
+		Status:         resp.StatusCode,
+		ResponseHeader: cassetteHeaders(resp.Header),
+		ResponseBody:   r.redact(respBody),
+		RecordedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("write cassette: %w", err)
+	}
+	return resp, nil
+}
+
+// redact strips secrets from a body. Callers must hold r.mu.
+func (r *Recorder) redact(body []byte) string {
+	s := string(redactCassetteBody(body))
+	if r.token != "" {
+		s = strings.ReplaceAll(s, r.token, redactedToken)
+	}
+	return s
+}
+
+// cassetteTail closes the interactions array and the Cassette object.
+const cassetteTail = "\n]}\n"
+
+// save appends it to the cassette file by overwriting the closing
+// cassetteTail, so each exchange costs one small write. Callers must hold
+// r.mu.
+func (r *Recorder) save(it Interaction) error {
+	data, err := json.MarshalIndent(it, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	if r.n == 0 {
+		data = slices.Concat([]byte(`{"interactions": [`+"\n  "), data, []byte(cassetteTail))
+		if err := os.WriteFile(r.path, data, 0o600); err != nil {
+			return err
+		}
+		r.n++
+		return nil
+	}
+
+	f, err := os.OpenFile(r.path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(-int64(len(cassetteTail)), io.SeekEnd)
+	if err == nil {
+		_, err = f.Write(slices.Concat([]byte(",\n  "), data, []byte(cassetteTail)))
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		r.n++
+	}
+	return err
+}
+
+// redactCassetteBody masks passwords and auth tokens in a JSON body.
+func redactCassetteBody(body []byte) []byte {
+	body = RedactJSON(body, redactedSecret, "password")
+	return RedactJSON(body, redactedToken, "auth_token")
+}
+
+// cassetteHeaders keeps only the response headers the client reacts to.
+func cassetteHeaders(h http.Header) http.Header {
+	out := http.Header{}
+	for _, k := range []string{"Content-Type", "Retry-After"} {
+		if v := h.Get(k); v != "" {
+			out.Set(k, v)
+		}
+	}
+	return out
+}
+
+// Replayer is an http.RoundTripper that serves a recorded cassette. Each
+// request gets the first unused interaction with the same method and path,
+// preferring one whose recorded body matches as well. Requests with no
+// recording left fail permanently instead of reaching the network, and
+// recorded Retry-After headers are dropped so replay never waits.
+type Replayer struct {
+	mu   sync.Mutex
+	tape []Interaction
+	used []bool
+}
+
+// LoadReplayer reads the cassette at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	return &Replayer{tape: c.Interactions, used: make([]bool, len(c.Interactions))}, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	body := string(redactCassetteBody(reqBody))
+	path := req.URL.RequestURI()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, it := range r.tape {
+		if r.used[i] || it.Method != req.Method || it.Path != path {
+			continue
+		}
+		if it.RequestBody == body {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		// Retrying can't make a recording appear.
+		return nil, permanent(notSentError{fmt.Errorf("cassette: no recorded response left for %s %s", req.Method, path)})
+	}
+	r.used[match] = true
+
+	it := r.tape[match]
+	header := it.ResponseHeader.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Retry-After")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(it.ResponseBody)),
+		ContentLength: int64(len(it.ResponseBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package oms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"oms-automtion/config"
+)
+
+func TestCassetteRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec := &http.Client{Transport: NewRecorder(path, http.DefaultTransport)}
+	for _, p := range []string{"/a", "/b", "/c"} {
+		resp, err := rec.Get(srv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	r, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.tape) != 3 {
+		t.Fatalf("cassette has %d interactions, want 3", len(r.tape))
+	}
+
+	c := &Client{
+		HTTPClient: &http.Client{Transport: r},
+		Retry:      RetryPolicy{MaxAttempts: 3},
+		Profile:    config.Profile{BaseURL: srv.URL},
+		Limiter:    NewRateLimiter(0, 1), // unlimited, but honours pauses
+	}
+	ctx, attempts := CountAttempts(context.Background())
+	status, body, err := c.do(ctx, "GET", srv.URL+"/b", nil)
+	if err != nil || status != 200 || string(body) != `{"path": "/b"}` {
+		t.Fatalf("replay /b = %d %s, %v", status, body, err)
+	}
+	if c.Limiter.reserve() != 0 {
+		t.Error("replayed Retry-After paused the limiter")
+	}
+
+	// /b is used up; a miss must not be retried.
+	if _, _, err := c.do(ctx, "GET", srv.URL+"/b", nil); err == nil {
+		t.Fatal("second replay of /b succeeded")
+	}
+	if got := attempts(); got != 2 {
+		t.Errorf("%d HTTP attempts, want 2", got)
+	}
+}
@@ -2,34 +2,131 @@ package oms
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"oms-automtion/config"
 	"oms-automtion/models"
 )
 
+// ErrAuth is returned (wrapped) when OMS keeps rejecting the session even
+// after a fresh login. Callers should treat it as fatal for the whole run
+// rather than failing outages one by one.
+var ErrAuth = errors.New("OMS authentication failed")
+
 type Client struct {
 	Token      string
 	HTTPClient *http.Client
+	Retry      RetryPolicy
+	// Profile selects the OMS environment: base URL, headers and page size.
+	Profile config.Profile
+	// Limiter paces every outbound request, including logins and retries.
+	// NewClient hands every client of a profile the same limiter.
+	Limiter *RateLimiter
+	// Creds overrides config.Creds() for logins, e.g. to try a new password.
+	Creds *config.Credentials
+	// TokenCache, if set, lets Login reuse a token from an earlier run.
+	TokenCache *TokenCache
+
+	tokenExpiry time.Time    // zero until logged in
+	refreshAt   time.Time    // when doOnce renews the token proactively
+	submits     atomic.Int64 // successful submits, for PendingOutages paging
 }
 
+// NewClient returns a client for the active config profile.
 func NewClient() *Client {
+	p := config.ActiveProfile()
 	return &Client{
-		HTTPClient: http.DefaultClient,
+		HTTPClient: defaultHTTPClient(),
+		Retry:      DefaultRetryPolicy(),
+		Profile:    p,
+		Limiter:    limiterFor(p),
+		TokenCache: NewTokenCache(),
+	}
+}
+
+// refreshMargin is how long before expiry a token is treated as expired.
+const refreshMargin = time.Duration(config.TokenRefreshMargin) * time.Millisecond
+
+// renewAt is when a token expiring at exp should be renewed: refreshMargin
+// before expiry, or halfway through what is left for tokens shorter-lived
+// than that, so a fresh token isn't renewed on every call.
+func renewAt(exp time.Time) time.Time {
+	margin := max(0, min(refreshMargin, time.Until(exp)/2))
+	return exp.Add(-margin)
+}
+
+// redactToken shortens a token for log lines. Short tokens are hidden
+// entirely rather than shown nearly whole.
+func redactToken(token string) string {
+	if len(token) < 24 {
+		return fmt.Sprintf("[%d chars]", len(token))
 	}
+	return token[:10] + "..." + token[len(token)-8:]
 }
 
-// Login authenticates and sets the client's Token fields.
-func (c *Client) Login() error {
+// rawResponse is one fully-read OMS response.
+type rawResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// callTimeout is config.RequestTimeout; tests shorten it.
+var callTimeout = time.Duration(config.RequestTimeout) * time.Millisecond
+
+// withCallTimeout bounds a single OMS round-trip by config.RequestTimeout,
+// on top of whatever deadline the caller's context already carries.
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, callTimeout)
+}
+
+// wait blocks until the rate limiter lets a request through. It runs on the
+// caller's context, before withCallTimeout, so that a long Retry-After pause
+// doesn't use up the per-call timeout.
+func (c *Client) wait(ctx context.Context) error {
+	if c.Limiter == nil {
+		return nil
+	}
+	if err := c.Limiter.Wait(ctx); err != nil {
+		return notSentError{err}
+	}
+	return nil
+}
+
+// Login makes the client ready for API calls. A cached token that is still
+// valid is reused; otherwise Login authenticates against OMS.
+func (c *Client) Login(ctx context.Context) error {
+	if c.Creds == nil {
+		empNo := config.Creds().EmpNo
+		if token, exp, ok := c.TokenCache.Load(c.Profile.BaseURL, empNo, 0); ok {
+			c.Token, c.tokenExpiry, c.refreshAt = token, exp, renewAt(exp)
+			log.Printf("  ✓ Reusing cached token for empNo=%s (%s)", empNo, describeExpiry(exp))
+			return nil
+		}
+	}
+	return c.login(ctx)
+}
+
+// login authenticates and sets the client's Token fields, caching the new
+// token when the client uses the configured credentials.
+func (c *Client) login(ctx context.Context) error {
+	creds := config.Creds()
+	if c.Creds != nil {
+		creds = *c.Creds
+	}
 	payload := models.LoginRequest{
-		CompanyName: config.Creds.CompanyName,
-		EmpNo:       config.Creds.EmpNo,
-		Password:    config.Creds.Password,
-		AppName:     config.Creds.AppName,
+		CompanyName: creds.CompanyName,
+		EmpNo:       creds.EmpNo,
+		Password:    creds.Password,
+		AppName:     c.Profile.AppName,
 	}
 
 	body, err := json.Marshal(payload)
@@ -37,32 +134,33 @@ func (c *Client) Login() error {
 		return fmt.Errorf("marshal login: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", config.BaseURL+"/auth/login", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("create login request: %w", err)
-	}
-	// Login sends "bearer null" initially — no valid token yet
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
-	req.Header.Set("Authorization", "bearer null")
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Referer", "https://smartoms.geourja.com/")
+	resp, err := c.withRetry(ctx, "login", retryable, func() (rawResponse, error) {
+		if err := c.wait(ctx); err != nil {
+			return rawResponse{}, err
+		}
+		ctx, cancel := withCallTimeout(ctx)
+		defer cancel()
 
-	resp, err := c.HTTPClient.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "POST", c.Profile.BaseURL+"/auth/login", bytes.NewReader(body))
+		if err != nil {
+			return rawResponse{}, fmt.Errorf("create login request: %w", err)
+		}
+		// Login sends "bearer null" initially — no valid token yet
+		req.Header.Set("Accept", "*/*")
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("Authorization", "bearer null")
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+		req.Header.Set("Referer", c.Profile.Referer)
+		return c.send(req)
+	})
 	if err != nil {
 		return fmt.Errorf("login HTTP: %w", err)
 	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
+	respBody := resp.Body
 
-	// DEBUG — remove after fixing
-	// log.Printf("  [DEBUG] Login request body: %s", string(body))
-	// log.Printf("  [DEBUG] Login status code: %d", resp.StatusCode)
-	// log.Printf("  [DEBUG] Login raw response: %s", string(respBody))
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("login returned %d: %s", resp.StatusCode, string(respBody))
+	if resp.Status != 200 {
+		return newAPIError("login", c.Profile.BaseURL+"/auth/login", resp)
 	}
 
 	var loginResp models.LoginResponse
@@ -74,19 +172,32 @@ func (c *Client) Login() error {
 		return fmt.Errorf("login failed: auth_token missing")
 	}
 
-	c.Token = loginResp.User.AuthToken
-	log.Printf("  ✓ Logged in as empNo=%s | token=%s...%s",
-		config.Creds.EmpNo, c.Token[:10], c.Token[len(c.Token)-8:])
+	token := loginResp.User.AuthToken
+	exp, fromJWT := tokenExpiry(token, config.TokenLifetime())
+	if !exp.After(time.Now()) {
+		return fmt.Errorf("login failed: OMS issued a token that expired at %s", exp.Format(time.DateTime))
+	}
+	c.Token, c.tokenExpiry, c.refreshAt = token, exp, renewAt(exp)
+	log.Printf("  ✓ Logged in as empNo=%s | token=%s", creds.EmpNo, redactToken(c.Token))
+
+	if c.Creds == nil && c.TokenCache != nil {
+		if !fromJWT {
+			log.Printf("  ⚙ Token is not a JWT; assuming it %s", describeExpiry(exp))
+		}
+		if err := c.TokenCache.Store(c.Profile.BaseURL, creds.EmpNo, c.Token, exp); err != nil {
+			log.Printf("  [WARN] token cache: %v", err)
+		}
+	}
 	return nil
 }
 
 // NewAPIRequest builds an http.Request with all required OMS headers
-func (c *Client) NewAPIRequest(method, url string, body []byte) (*http.Request, error) {
+func (c *Client) NewAPIRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		bodyReader = bytes.NewReader(body)
 	}
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +206,93 @@ func (c *Client) NewAPIRequest(method, url string, body []byte) (*http.Request,
 	req.Header.Set("Authorization", "bearer "+c.Token)
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Referer", "https://smartoms.geourja.com/")
+	req.Header.Set("Referer", c.Profile.Referer)
 	return req, nil
 }
+
+// do sends an API request built by NewAPIRequest and returns the status code
+// and the fully-read body. Transient failures are retried according to
+// c.Retry; see doAuthed for how expired tokens are handled.
+func (c *Client) do(ctx context.Context, method, url string, body []byte) (int, []byte, error) {
+	resp, err := c.withRetry(ctx, method+" "+url, retryable, func() (rawResponse, error) {
+		return c.doAuthed(ctx, method, url, body)
+	})
+	return resp.Status, resp.Body, err
+}
+
+// doAuthed performs one API round-trip. If OMS answers 401 the token has most
+// likely expired, so doAuthed logs in again once and replays the request with
+// the new token. A second 401, or a failed re-login, is reported as ErrAuth.
+func (c *Client) doAuthed(ctx context.Context, method, url string, body []byte) (rawResponse, error) {
+	resp, err := c.doOnce(ctx, method, url, body)
+	if err != nil || resp.Status != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	log.Printf("  ⟳ OMS returned 401 for %s %s — logging in again", method, url)
+	if err := c.TokenCache.Invalidate(); err != nil {
+		log.Printf("  [WARN] token cache: %v", err)
+	}
+	// login retries on its own, and ErrAuth stops the caller's retry loop, so
+	// a failing re-login costs at most one round of login attempts.
+	if err := c.login(ctx); err != nil {
+		if ctx.Err() != nil {
+			return rawResponse{}, err
+		}
+		return rawResponse{}, fmt.Errorf("%w: re-login: %v", ErrAuth, err)
+	}
+
+	resp, err = c.doOnce(ctx, method, url, body)
+	if err == nil && resp.Status == http.StatusUnauthorized {
+		return resp, fmt.Errorf("%w: still 401 after re-login: %s", ErrAuth, sanitizeBody(resp.Body))
+	}
+	return resp, err
+}
+
+// doOnce performs a single API round-trip under the per-call deadline. A
+// token about to expire is renewed first rather than waiting for a 401.
+func (c *Client) doOnce(ctx context.Context, method, url string, body []byte) (rawResponse, error) {
+	if !c.refreshAt.IsZero() && !time.Now().Before(c.refreshAt) {
+		log.Printf("  ⟳ Token %s — logging in again", describeExpiry(c.tokenExpiry))
+		// login retries on its own; don't repeat it from the caller's loop.
+		if err := c.login(ctx); err != nil {
+			return rawResponse{}, permanent(notSentError{fmt.Errorf("renew token: %w", err)})
+		}
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return rawResponse{}, err
+	}
+	ctx, cancel := withCallTimeout(ctx)
+	defer cancel()
+
+	req, err := c.NewAPIRequest(ctx, method, url, body)
+	if err != nil {
+		return rawResponse{}, err
+	}
+	return c.send(req)
+}
+
+// send executes req and reads the whole body, so the request's deadline also
+// covers slow response streams. Callers wait for the rate limiter first.
+// Every call counts as one attempt.
+func (c *Client) send(req *http.Request) (rawResponse, error) {
+	countAttempt(req.Context())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return rawResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	// OMS asked everyone to slow down, not just this call.
+	if d := retryAfter(resp.Header); d > 0 && c.Limiter != nil {
+		c.Limiter.PauseFor(d)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return rawResponse{}, fmt.Errorf("read response: %w", err)
+	}
+	return rawResponse{Status: resp.StatusCode, Header: resp.Header, Body: respBody}, nil
+}
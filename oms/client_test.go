@@ -0,0 +1,86 @@
+package oms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oms-automtion/config"
+)
+
+func TestRenewAt(t *testing.T) {
+	tests := []struct {
+		name string
+		left time.Duration // until expiry
+		want time.Duration // from now until renewal
+	}{
+		{"long-lived", time.Hour, time.Hour - refreshMargin},
+		{"shorter than margin", 30 * time.Second, 15 * time.Second},
+		{"already expired", -time.Minute, -time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			got := renewAt(now.Add(tt.left)).Sub(now)
+			if d := got - tt.want; d < -time.Second || d > time.Second {
+				t.Errorf("renewAt(now%+v) = now%+v, want now%+v", tt.left, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	tests := []struct{ token, want string }{
+		{"", "[0 chars]"},
+		{"short", "[5 chars]"},
+		{"eyJhbGciOiJIUzI1NiJ9.payload.signature", "eyJhbGciOi...ignature"},
+	}
+	for _, tt := range tests {
+		if got := redactToken(tt.token); got != tt.want {
+			t.Errorf("redactToken(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+// A failing re-login must not be repeated by the retry loop of the call
+// that triggered it: one round of login attempts, then give up.
+func TestReloginNotRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/login" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name      string
+		refreshAt time.Time
+		want      int // HTTP attempts
+	}{
+		{"after 401", time.Time{}, 1 + 3},
+		{"proactive renewal", time.Now().Add(-time.Second), 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				HTTPClient:  srv.Client(),
+				Retry:       RetryPolicy{MaxAttempts: 3},
+				Profile:     config.Profile{BaseURL: srv.URL},
+				Token:       "expired",
+				Creds:       &config.Credentials{EmpNo: "1"},
+				tokenExpiry: tt.refreshAt,
+				refreshAt:   tt.refreshAt,
+			}
+			ctx, attempts := CountAttempts(context.Background())
+			if _, _, err := c.do(ctx, "GET", srv.URL+"/api", nil); err == nil {
+				t.Fatal("do succeeded, want an error")
+			}
+			if got := attempts(); got != tt.want {
+				t.Errorf("%d HTTP attempts, want %d", got, tt.want)
+			}
+		})
+	}
+}
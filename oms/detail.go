@@ -0,0 +1,105 @@
+package oms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"oms-automtion/models"
+)
+
+// decodeOutageData decodes data.outageData, which OMS sends either as an
+// object or as a one-element array. Unknown keys are kept in Extra.
+func decodeOutageData(raw json.RawMessage) (models.OutageData, error) {
+	var out models.OutageData
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return out, nil
+	}
+	if raw[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return out, err
+		}
+		if len(list) == 0 {
+			return out, nil
+		}
+		raw = list[0]
+	}
+
+	extra, err := decodeWithExtra(raw, &out)
+	if err != nil {
+		return out, err
+	}
+	out.Extra = extra
+	return out, nil
+}
+
+// decodeFeederMetadata decodes a metadata object from feederPointGeoJson.
+func decodeFeederMetadata(raw json.RawMessage) (models.FeederMetadata, error) {
+	var out models.FeederMetadata
+	extra, err := decodeWithExtra(raw, &out)
+	if err != nil {
+		return out, err
+	}
+	out.Extra = extra
+	return out, nil
+}
+
+// decodeWithExtra unmarshals raw into v (a pointer to a struct) and returns
+// the object keys that none of v's fields claim.
+func decodeWithExtra(raw json.RawMessage, v any) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	for _, k := range jsonKeys(reflect.TypeOf(v).Elem()) {
+		delete(all, k)
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// jsonKeys lists the JSON keys of t's fields, descending into embedded structs.
+func jsonKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			keys = append(keys, jsonKeys(f.Type)...)
+			continue
+		}
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		keys = append(keys, tag)
+	}
+	return keys
+}
+
+// DescribeFacts renders the fault facts of d for log lines, or "".
+func DescribeFacts(d models.OutageData) string {
+	var parts []string
+	add := func(name, v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", name, v))
+		}
+	}
+	add("fault", d.FaultLocation)
+	add("breaker", d.BreakerName)
+	add("trip", d.TripType)
+	add("relay", d.RelayIndication)
+	add("remarks", d.RestorationRemarks)
+	return strings.Join(parts, " ")
+}
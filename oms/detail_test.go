@@ -0,0 +1,84 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oms-automtion/config"
+)
+
+// outageDataObject is OUT-1001's outageData from the fake OMS scenario (with
+// a recorded reason): the outage columns, the fault facts and a key we don't
+// model. Swap in a captured production response when one is available.
+const outageDataObject = `{"id": "OUT-1001", "feeder_id": 101, "feeder_name": "ANAND NAGAR",
+	"outage_occur_date": "2026-01-28", "outage_occur_time": "17:37:25.743",
+	"outage_restore_date": "2026-01-28", "outage_restore_time": "17:46:10.120",
+	"fault_location": "Near Kapodra char rasta", "breaker_name": "VCB-3", "trip_type": "E/F",
+	"relay_indication": "Earth fault", "restoration_remarks": "Charged after patrolling",
+	"patrolled_by": "LM Patel", "reason_id": 21, "loc_id": 500102}`
+
+func TestDecodeOutageData(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		wantID   string
+		wantLoc  int
+		wantRest bool // "patrolled_by" kept in Extra
+	}{
+		{"object", outageDataObject, "OUT-1001", 500102, true},
+		{"array-wrapped", "[" + outageDataObject + "]", "OUT-1001", 500102, true},
+		{"null", "null", "", 0, false},
+		{"empty array", "[]", "", 0, false},
+		{"missing", "", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeOutageData(json.RawMessage(tt.raw))
+			if err != nil {
+				t.Fatalf("decodeOutageData: %v", err)
+			}
+			if got.ID != tt.wantID || got.LocID != tt.wantLoc {
+				t.Errorf("got id %q loc_id %d, want %q %d", got.ID, got.LocID, tt.wantID, tt.wantLoc)
+			}
+			if _, ok := got.Extra["patrolled_by"]; ok != tt.wantRest {
+				t.Errorf("Extra = %v, want patrolled_by kept: %v", got.Extra, tt.wantRest)
+			}
+			if tt.wantID != "" && (got.TripType != "E/F" || got.ReasonID != 21) {
+				t.Errorf("facts not decoded: %+v", got)
+			}
+		})
+	}
+
+	if _, err := decodeOutageData(json.RawMessage(`{"feeder_id": "101"}`)); err == nil {
+		t.Error("feeder_id as a string: want a decode error")
+	}
+}
+
+func TestFetchOutageDetailKeepsUndecodedOutageData(t *testing.T) {
+	const outageData = `{"feeder_id": "101", "fault_location": 7}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": true, "data": {"outageData": ` + outageData + `,
+			"feederPointGeoJson": [[{"row_to_json": {"type": "FeatureCollection", "features": [
+				{"type": "Feature", "properties": {"hlt": "HT Pole", "id": 500101}}]}}]]}}`))
+	}))
+	defer srv.Close()
+	c := &Client{
+		HTTPClient: srv.Client(),
+		Retry:      RetryPolicy{MaxAttempts: 1},
+		Profile:    config.Profile{BaseURL: srv.URL},
+	}
+
+	detail, err := c.FetchOutageDetail(context.Background(), "OUT-1", 101)
+	if err != nil {
+		t.Fatalf("FetchOutageDetail: %v", err)
+	}
+	if got := string(detail.Data.Extra["outageData"]); got != outageData {
+		t.Errorf("Extra[outageData] = %s, want the raw value %s", got, outageData)
+	}
+	if len(detail.PoleIDs) != 1 || detail.PoleIDs[0] != 500101 {
+		t.Errorf("PoleIDs = %v, want [500101]", detail.PoleIDs)
+	}
+}
@@ -0,0 +1,124 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrSubmitUnknown is returned (wrapped) when a submit failed in a way that
+// leaves open whether OMS recorded it, e.g. the connection dropped after the
+// request went out. Such a submit must not be repeated blindly.
+var ErrSubmitUnknown = errors.New("submit outcome unknown")
+
+// maxErrorBody caps how much of a response body an APIError keeps.
+const maxErrorBody = 200
+
+// APIError is a non-2xx answer from OMS. Body is sanitized (credentials and
+// tokens masked) and truncated, so the error is safe to log and show.
+type APIError struct {
+	Op        string // "login" | "pending" | "detail" | "submit"
+	Path      string // request path, e.g. /reason/outage/OUT-1001
+	Status    int
+	Message   string // OMS's "message" field, if the body had one
+	Body      string
+	Retryable bool // 429 and 5xx; the client already retried these
+}
+
+func (e *APIError) Error() string {
+	detail := e.Message
+	if detail == "" {
+		detail = e.Body
+	}
+	if detail == "" {
+		detail = http.StatusText(e.Status)
+	}
+	return fmt.Sprintf("%s %s returned %d: %s", e.Op, e.Path, e.Status, detail)
+}
+
+// newAPIError builds the APIError for an unexpected response to rawURL.
+func newAPIError(op, rawURL string, resp rawResponse) *APIError {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	return &APIError{
+		Op:        op,
+		Path:      path,
+		Status:    resp.Status,
+		Message:   omsMessage(resp.Body),
+		Body:      sanitizeBody(resp.Body),
+		Retryable: resp.Status == http.StatusTooManyRequests || resp.Status >= 500,
+	}
+}
+
+// omsMessage returns the "message" (or "error") string of a JSON body.
+func omsMessage(body []byte) string {
+	var v struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return ""
+	}
+	if v.Message != "" {
+		return strings.TrimSpace(v.Message)
+	}
+	return strings.TrimSpace(v.Error)
+}
+
+// sanitizeBody masks secrets in body, flattens whitespace and truncates it.
+func sanitizeBody(body []byte) string {
+	body = RedactJSON(body, redactedSecret, "password", "auth_token", "token", "empNo")
+	s := strings.Join(strings.Fields(string(body)), " ")
+	if r := []rune(s); len(r) > maxErrorBody {
+		s = string(r[:maxErrorBody-1]) + "…"
+	}
+	return s
+}
+
+// Cause sorts err into a short, human-readable failure category, used to
+// group failures in run results, e.g. "OMS rejected: outage already closed"
+// or "OMS unavailable (HTTP 502)". It returns "" for a nil error.
+func Cause(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrAuth) {
+		return "OMS authentication failed"
+	}
+	if errors.Is(err, ErrSubmitUnknown) {
+		return "submit outcome unknown"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "cancelled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "OMS timed out"
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Status == http.StatusTooManyRequests:
+			return "OMS rate limited"
+		case apiErr.Status >= 500:
+			return fmt.Sprintf("OMS unavailable (HTTP %d)", apiErr.Status)
+		case apiErr.Message != "":
+			return "OMS rejected: " + strings.ToLower(apiErr.Message)
+		default:
+			return fmt.Sprintf("OMS rejected (HTTP %d)", apiErr.Status)
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network error"
+	}
+	return "other error"
+}
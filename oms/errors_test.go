@@ -0,0 +1,64 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCause(t *testing.T) {
+	var syntaxErr error
+	if err := json.Unmarshal([]byte("<html>"), new(any)); err != nil {
+		syntaxErr = fmt.Errorf("unmarshal detail OUT-1: %w", err)
+	}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"rate limited", &APIError{Op: "submit", Status: 429}, "OMS rate limited"},
+		{"502", fmt.Errorf("fetch detail: %w", &APIError{Op: "detail", Status: 502}), "OMS unavailable (HTTP 502)"},
+		{"503", &APIError{Op: "pending", Status: 503, Message: "maintenance"}, "OMS unavailable (HTTP 503)"},
+		{"rejected with message", &APIError{Op: "submit", Status: 400, Message: "Outage Already Closed"}, "OMS rejected: outage already closed"},
+		{"rejected without message", &APIError{Op: "submit", Status: 404}, "OMS rejected (HTTP 404)"},
+		{"decode", syntaxErr, "other error"},
+		{"auth", fmt.Errorf("%w: re-login: %v", ErrAuth, &APIError{Status: 500}), "OMS authentication failed"},
+		{"unknown submit", fmt.Errorf("%w: %w", ErrSubmitUnknown, &APIError{Status: 500}), "submit outcome unknown"},
+		{"cancelled", fmt.Errorf("fetch: %w", context.Canceled), "cancelled"},
+		{"timeout", fmt.Errorf("fetch: %w", context.DeadlineExceeded), "OMS timed out"},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}, "network error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cause(tt.err); got != tt.want {
+				t.Errorf("Cause(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeBody(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{
+			name: "secrets masked",
+			body: `{"empNo": "12345", "password": "p", "user": {"auth_token": "eyJ.x.y"}, "message": "bad"}`,
+			want: `{"empNo": "[REDACTED]", "password": "[REDACTED]", "user": {"auth_token": "[REDACTED]"}, "message": "bad"}`,
+		},
+		{"whitespace flattened", "<html>\n  <body>Bad\tGateway</body>\n</html>", "<html> <body>Bad Gateway</body> </html>"},
+		{"long body truncated", strings.Repeat("ઓ", maxErrorBody+10), strings.Repeat("ઓ", maxErrorBody-1) + "…"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("sanitizeBody:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package oms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oms-automtion/models"
+)
+
+// Field names and operators sent in the /reason/pending grid filter. The
+// field names are the outage's own JSON keys; the types and operators follow
+// the usual grid conventions but have not been checked against a recorded OMS
+// request, which is why PendingOutages re-applies the filter client-side.
+const (
+	filterTypeText = "string"
+	filterTypeDate = "date"
+	filterEquals   = "equals"
+	filterBetween  = "between"
+	dateLayout     = "2006-01-02"
+)
+
+// FilterData converts f into the filteredData array sent to /reason/pending.
+// It rejects malformed or inverted date ranges.
+func FilterData(f models.PendingFilter) ([]models.FilteredData, error) {
+	out := []models.FilteredData{}
+	add := func(field, typ, op, value, from, to string) {
+		out = append(out, models.FilteredData{
+			ID:        len(out) + 1,
+			Field:     field,
+			Type:      typ,
+			Operator:  op,
+			Value:     value,
+			FromValue: from,
+			ToValue:   to,
+		})
+	}
+	text := func(field, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			add(field, filterTypeText, filterEquals, value, "", "")
+		}
+	}
+
+	text("feeder_name", f.Feeder)
+	text("ss_name", f.Substation)
+	text("discom_circle_name", f.Circle)
+	text("discom_division_name", f.Division)
+	text("subdivision_name", f.Subdivision)
+	if t := strings.TrimSpace(f.OutageType); t != "" {
+		if _, err := strconv.Atoi(t); err == nil {
+			text("outage_type", t)
+		} else {
+			text("outage_type_name", t)
+		}
+	}
+
+	if f.From != "" || f.To != "" {
+		from, to := strings.TrimSpace(f.From), strings.TrimSpace(f.To)
+		if from == "" {
+			from = to
+		}
+		if to == "" {
+			to = from
+		}
+		fromT, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return nil, fmt.Errorf("filter from date %q: want YYYY-MM-DD", from)
+		}
+		toT, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return nil, fmt.Errorf("filter to date %q: want YYYY-MM-DD", to)
+		}
+		if toT.Before(fromT) {
+			return nil, fmt.Errorf("filter date range %s..%s is inverted", from, to)
+		}
+		add("outage_occur_date", filterTypeDate, filterBetween, "", from, to)
+	}
+	return out, nil
+}
+
+// MatchesFilter reports whether o passes every entry of a filteredData array.
+// PendingOutages uses it to drop outages a server returned despite the
+// filter, and in-process stand-ins use it to filter like OMS. Text
+// comparisons ignore case; unknown fields never match.
+func MatchesFilter(o models.Outage, filters []models.FilteredData) bool {
+	for _, f := range filters {
+		var got string
+		switch f.Field {
+		case "feeder_name":
+			got = o.FeederName
+		case "ss_name":
+			got = o.SSName
+		case "discom_circle_name":
+			got = o.DiscomCircleName
+		case "discom_division_name":
+			got = o.DiscomDivisionName
+		case "subdivision_name":
+			got = o.SubdivisionName
+		case "outage_type":
+			got = strconv.Itoa(o.OutageType)
+		case "outage_type_name":
+			got = o.OutageTypeName
+		case "outage_occur_date":
+			got = o.OutageOccurDate
+		default:
+			return false
+		}
+
+		switch f.Operator {
+		case filterEquals:
+			if !strings.EqualFold(strings.TrimSpace(got), f.Value) {
+				return false
+			}
+		case filterBetween:
+			// YYYY-MM-DD compares correctly as a string.
+			if got < f.FromValue || got > f.ToValue {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// DescribeFilter renders f for log lines, or "" if it filters nothing.
+func DescribeFilter(f models.PendingFilter) string {
+	var parts []string
+	add := func(name, v string) {
+		if v != "" {
+			parts = append(parts, name+"="+v)
+		}
+	}
+	add("feeder", f.Feeder)
+	add("substation", f.Substation)
+	add("circle", f.Circle)
+	add("division", f.Division)
+	add("subdivision", f.Subdivision)
+	add("outage_type", f.OutageType)
+	add("from", f.From)
+	add("to", f.To)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,130 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+)
+
+func TestFilterData(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  models.PendingFilter
+		want    []models.FilteredData
+		wantErr bool
+	}{
+		{name: "empty", want: []models.FilteredData{}},
+		{
+			name:   "text fields trimmed",
+			filter: models.PendingFilter{Feeder: " KUMBHIYA ", Circle: "SURAT RURAL"},
+			want: []models.FilteredData{
+				{ID: 1, Field: "feeder_name", Type: "string", Operator: "equals", Value: "KUMBHIYA"},
+				{ID: 2, Field: "discom_circle_name", Type: "string", Operator: "equals", Value: "SURAT RURAL"},
+			},
+		},
+		{
+			name:   "numeric outage type",
+			filter: models.PendingFilter{OutageType: "1"},
+			want:   []models.FilteredData{{ID: 1, Field: "outage_type", Type: "string", Operator: "equals", Value: "1"}},
+		},
+		{
+			name:   "named outage type",
+			filter: models.PendingFilter{OutageType: "Unplanned"},
+			want:   []models.FilteredData{{ID: 1, Field: "outage_type_name", Type: "string", Operator: "equals", Value: "Unplanned"}},
+		},
+		{
+			name:   "single day",
+			filter: models.PendingFilter{From: "2026-01-28"},
+			want: []models.FilteredData{
+				{ID: 1, Field: "outage_occur_date", Type: "date", Operator: "between", FromValue: "2026-01-28", ToValue: "2026-01-28"},
+			},
+		},
+		{name: "bad date", filter: models.PendingFilter{From: "28/01/2026"}, wantErr: true},
+		{name: "inverted range", filter: models.PendingFilter{From: "2026-01-29", To: "2026-01-28"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterData(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FilterData error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterData:\n got  %+v\n want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	o := models.Outage{FeederName: "KUMBHIYA", OutageType: 1, OutageTypeName: "Unplanned", OutageOccurDate: "2026-01-28"}
+	tests := []struct {
+		name   string
+		filter models.PendingFilter
+		want   bool
+	}{
+		{"no filter", models.PendingFilter{}, true},
+		{"feeder any case", models.PendingFilter{Feeder: "kumbhiya"}, true},
+		{"other feeder", models.PendingFilter{Feeder: "KUMBH"}, false},
+		{"outage type id", models.PendingFilter{OutageType: "1"}, true},
+		{"outage type name", models.PendingFilter{OutageType: "Planned"}, false},
+		{"in range", models.PendingFilter{From: "2026-01-27", To: "2026-01-28"}, true},
+		{"after range", models.PendingFilter{From: "2026-01-29"}, false},
+		{"one field fails", models.PendingFilter{Feeder: "KUMBHIYA", Substation: "KAMREJ"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterData(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := MatchesFilter(o, filtered); got != tt.want {
+				t.Errorf("MatchesFilter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if MatchesFilter(o, []models.FilteredData{{Field: "feeder_id", Operator: "equals", Value: "0"}}) {
+		t.Error("unknown field matched")
+	}
+}
+
+// TestPendingOutagesFilterIgnored checks that a server which ignores
+// filteredData can't widen a run beyond the filter.
+func TestPendingOutagesFilterIgnored(t *testing.T) {
+	all := []models.Outage{
+		{ID: "1", FeederName: "KUMBHIYA"},
+		{ID: "2", FeederName: "SAROLI"},
+		{ID: "3", FeederName: "KUMBHIYA"},
+		{ID: "4", FeederName: "LASKANA"},
+		{ID: "5", FeederName: "kumbhiya"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.PendingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		lo, hi := min(req.Offset, len(all)), min(req.Offset+req.Limit, len(all))
+		json.NewEncoder(w).Encode(models.PendingResponse{TotalRecords: len(all), Data: all[lo:hi]})
+	}))
+	defer srv.Close()
+	c := &Client{
+		HTTPClient: srv.Client(),
+		Retry:      RetryPolicy{MaxAttempts: 1},
+		Profile:    config.Profile{BaseURL: srv.URL, PageSize: 2},
+	}
+
+	var got []string
+	for o, err := range c.PendingOutages(context.Background(), models.PendingFilter{Feeder: "KUMBHIYA"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, o.ID)
+	}
+	if want := []string{"1", "3", "5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got outages %v, want %v", got, want)
+	}
+}
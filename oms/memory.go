@@ -0,0 +1,270 @@
+package oms
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+
+	"oms-automtion/models"
+)
+
+// Op names an API operation for failure injection in Memory.
+type Op string
+
+const (
+	OpLogin        Op = "login"
+	OpFetchPending Op = "fetch_pending"
+	OpFetchDetail  Op = "fetch_detail"
+	OpSubmitReason Op = "submit_reason"
+	anyOutage         = ""
+)
+
+// Memory is an in-memory API implementation. Outages and feeder poles are
+// scripted up front, failures can be injected per operation and outage, and
+// submitted reasons are recorded for inspection. It is safe for concurrent use.
+type Memory struct {
+	mu        sync.Mutex
+	loggedIn  bool
+	pending   []models.Outage
+	resolved  map[string]models.Outage // outages that received a reason
+	poles     map[int][]int            // feederID → HT pole IDs
+	details   map[string]models.OutageData
+	submitted map[string][]models.ReasonPayloadItem
+	failures  []*injectedFailure
+}
+
+type injectedFailure struct {
+	op       Op
+	outageID string
+	times    int // remaining; < 0 means forever
+	err      error
+}
+
+// NewMemory returns an empty Memory with no outages and no failures.
+func NewMemory() *Memory {
+	return &Memory{
+		poles:     map[int][]int{},
+		details:   map[string]models.OutageData{},
+		resolved:  map[string]models.Outage{},
+		submitted: map[string][]models.ReasonPayloadItem{},
+	}
+}
+
+// AddOutage queues o as pending. Any poles given are added to o's feeder.
+func (m *Memory) AddOutage(o models.Outage, poles ...int) *Memory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(m.pending, o)
+	m.poles[o.FeederID] = append(m.poles[o.FeederID], poles...)
+	return m
+}
+
+// SetPoles replaces the HT pole list of a feeder.
+func (m *Memory) SetPoles(feederID int, poles ...int) *Memory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.poles[feederID] = poles
+	return m
+}
+
+// SetDetail scripts the outage data FetchOutageDetail returns for an
+// outage. Without it, the outage's pending-list fields are returned.
+func (m *Memory) SetDetail(outageID string, d models.OutageData) *Memory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.details[outageID] = d
+	return m
+}
+
+// FailOn makes the next `times` calls of op fail with err (times < 0: every
+// call). An empty outageID matches every outage; it is ignored for OpLogin
+// and OpFetchPending. A nil err injects a generic failure.
+func (m *Memory) FailOn(op Op, outageID string, times int, err error) *Memory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		err = fmt.Errorf("injected %s failure", op)
+	}
+	m.failures = append(m.failures, &injectedFailure{op: op, outageID: outageID, times: times, err: err})
+	return m
+}
+
+// Submitted returns the reasons recorded for an outage, in submit order.
+func (m *Memory) Submitted(outageID string) []models.ReasonPayloadItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.ReasonPayloadItem(nil), m.submitted[outageID]...)
+}
+
+// Pending returns a copy of the outages still awaiting a reason.
+func (m *Memory) Pending() []models.Outage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.Outage(nil), m.pending...)
+}
+
+// injected returns the first armed failure matching op and outageID, using
+// one of its shots. Callers must hold m.mu.
+func (m *Memory) injected(op Op, outageID string) error {
+	for _, f := range m.failures {
+		if f.op != op || f.times == 0 {
+			continue
+		}
+		if f.outageID != anyOutage && f.outageID != outageID {
+			continue
+		}
+		if f.times > 0 {
+			f.times--
+		}
+		return f.err
+	}
+	return nil
+}
+
+func (m *Memory) Login(ctx context.Context) error {
+	countAttempt(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injected(OpLogin, anyOutage); err != nil {
+		return err
+	}
+	m.loggedIn = true
+	return nil
+}
+
+// PendingOutages yields a snapshot of the matching pending outages, taken
+// when iteration starts, as a single page.
+func (m *Memory) PendingOutages(ctx context.Context, filter models.PendingFilter) iter.Seq2[models.Outage, error] {
+	return func(yield func(models.Outage, error) bool) {
+		page, err := m.pendingPage(ctx, filter)
+		if err != nil {
+			yield(models.Outage{}, err)
+			return
+		}
+		for _, o := range page {
+			if !yield(o, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Memory) pendingPage(ctx context.Context, filter models.PendingFilter) ([]models.Outage, error) {
+	countAttempt(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	filtered, err := FilterData(filter)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loggedIn {
+		return nil, fmt.Errorf("%w: not logged in", ErrAuth)
+	}
+	if err := m.injected(OpFetchPending, anyOutage); err != nil {
+		return nil, err
+	}
+
+	var out []models.Outage
+	for _, o := range m.pending {
+		if MatchesFilter(o, filtered) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) FetchOutageDetail(ctx context.Context, outageID string, feederID int) (*models.OutageDetail, error) {
+	countAttempt(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loggedIn {
+		return nil, fmt.Errorf("%w: not logged in", ErrAuth)
+	}
+	if err := m.injected(OpFetchDetail, outageID); err != nil {
+		return nil, err
+	}
+	o, ok := m.resolved[outageID]
+	if i := m.indexOf(outageID); i >= 0 {
+		o, ok = m.pending[i], true
+	}
+	if !ok {
+		return nil, &APIError{Op: "detail", Path: "/reason/" + strconv.Itoa(feederID) + "/" + outageID, Status: 404, Message: "Outage not found"}
+	}
+
+	data, ok := m.details[outageID]
+	if !ok {
+		data = models.OutageData{Outage: o}
+	}
+	if items := m.submitted[outageID]; len(items) > 0 {
+		data.ReasonID, data.LocID = items[0].ReasonID, items[0].LocID
+	}
+	var topo models.FeederTopology
+	for _, id := range m.poles[feederID] {
+		topo.Features = append(topo.Features, models.FeederFeature{ID: id, Type: HTPole})
+	}
+	return &models.OutageDetail{
+		Data:     data,
+		Topology: topo,
+		PoleIDs:  HTPoleIDs(topo),
+	}, nil
+}
+
+func (m *Memory) SubmitReasons(ctx context.Context, outageID string, items []models.ReasonPayloadItem) error {
+	countAttempt(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loggedIn {
+		return fmt.Errorf("%w: not logged in", ErrAuth)
+	}
+	if err := m.injected(OpSubmitReason, outageID); err != nil {
+		return err
+	}
+	i := m.indexOf(outageID)
+	if i < 0 {
+		return &APIError{Op: "submit", Path: "/reason/outage/" + outageID, Status: 404, Message: "Outage not pending"}
+	}
+
+	if len(items) == 0 {
+		return fmt.Errorf("submit %s: no loc_id/reason_id pairs", outageID)
+	}
+
+	m.submitted[outageID] = append(m.submitted[outageID], items...)
+	m.resolved[outageID] = m.pending[i]
+	m.pending = append(m.pending[:i], m.pending[i+1:]...)
+	return nil
+}
+
+// indexOf returns the position of outageID in m.pending, or -1.
+// Callers must hold m.mu.
+func (m *Memory) indexOf(outageID string) int {
+	for i, o := range m.pending {
+		if o.ID == outageID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+package oms
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/utils"
+)
+
+// RateLimiter is a token bucket shared by every outbound OMS request. It
+// refills at a steady rate up to a burst size, and can be paused outright
+// when OMS asks us to back off with a Retry-After header.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // tokens per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter returns a limiter allowing rps requests per second on
+// average and up to burst requests back to back. rps <= 0 disables limiting.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*RateLimiter{}
+)
+
+// limiterFor returns the process-wide limiter for profile p, so every client
+// talking to the same OMS environment — runs, submits, password rotation —
+// shares one request budget and one Retry-After pause.
+func limiterFor(p config.Profile) *RateLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[p.Name]
+	if !ok {
+		l = NewRateLimiter(p.RateRPS, p.RateBurst)
+		limiters[p.Name] = l
+	}
+	return l
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve()
+		if wait <= 0 {
+			return nil
+		}
+		if err := utils.SleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token if one is available and returns 0, or returns how
+// long to wait before trying again.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// PauseFor holds back all requests for d, e.g. after a Retry-After header.
+// A shorter pause never cuts an existing one short.
+func (l *RateLimiter) PauseFor(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
@@ -0,0 +1,80 @@
+package oms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oms-automtion/config"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := NewRateLimiter(2, 2)
+	for i := range 2 {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("burst request %d waits %s, want 0", i+1, d)
+		}
+	}
+	if d := l.reserve(); d <= 0 || d > 500*time.Millisecond {
+		t.Errorf("request over burst waits %s, want (0, 500ms]", d)
+	}
+
+	l.tokens, l.last = 0, l.last.Add(-time.Second) // a second later: 2 tokens back
+	if d := l.reserve(); d != 0 {
+		t.Errorf("after refill waits %s, want 0", d)
+	}
+
+	if d := NewRateLimiter(0, 1).reserve(); d != 0 {
+		t.Errorf("unlimited limiter waits %s", d)
+	}
+}
+
+func TestRateLimiterPauseFor(t *testing.T) {
+	l := NewRateLimiter(0, 1)
+	l.PauseFor(time.Minute)
+	if d := l.reserve(); d < 59*time.Second || d > time.Minute {
+		t.Errorf("paused limiter waits %s, want about 1m", d)
+	}
+	l.PauseFor(time.Second) // must not cut the longer pause short
+	if d := l.reserve(); d < 59*time.Second {
+		t.Errorf("shorter pause shortened the wait to %s", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait during pause = %v, want deadline exceeded", err)
+	}
+}
+
+// A Retry-After pause longer than the per-call timeout must delay the
+// request, not time it out.
+func TestPauseLongerThanCallTimeout(t *testing.T) {
+	defer func(d time.Duration) { callTimeout = d }(callTimeout)
+	callTimeout = 200 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := &Client{
+		HTTPClient: srv.Client(),
+		Retry:      RetryPolicy{MaxAttempts: 2},
+		Profile:    config.Profile{BaseURL: srv.URL},
+		Limiter:    NewRateLimiter(0, 1),
+	}
+	c.Limiter.PauseFor(600 * time.Millisecond) // e.g. Retry-After seen by another client
+
+	ctx, attempts := CountAttempts(context.Background())
+	start := time.Now()
+	if status, _, err := c.do(ctx, "GET", srv.URL+"/api", nil); err != nil || status != 200 {
+		t.Fatalf("do = %d, %v; want 200", status, err)
+	}
+	if got := attempts(); got != 1 {
+		t.Errorf("%d attempts, want 1", got)
+	}
+	if d := time.Since(start); d < 500*time.Millisecond {
+		t.Errorf("request went out after %s, before the pause ended", d)
+	}
+}
@@ -0,0 +1,119 @@
+package oms
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// RedactJSON replaces the values of the given keys (matched case-insensitively,
+// at any depth) with mask. Everything else is copied byte for byte, so key
+// order, number formatting and whitespace survive. Bodies that aren't JSON
+// are returned unchanged.
+func RedactJSON(body []byte, mask string, keys ...string) []byte {
+	if len(body) == 0 || !json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(mask)
+
+	type frame struct{ object, wantKey bool }
+	var (
+		out   []byte
+		done  int // body[:done] is already in out
+		stack []frame
+	)
+	// valueDone notes that a complete value was read inside the current
+	// container, so an object expects a key next.
+	valueDone := func() {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].wantKey = true
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break // io.EOF: body was checked valid above
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				stack = append(stack, frame{object: true, wantKey: true})
+			case '[':
+				stack = append(stack, frame{})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+			continue
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].wantKey {
+				stack[n-1].wantKey = false
+				if !matchesKey(t, keys) {
+					continue
+				}
+				start := int(dec.InputOffset())
+				if skipValue(dec) != nil {
+					return body
+				}
+				end := int(dec.InputOffset())
+				start += len(body[start:end]) - len(bytes.TrimLeft(body[start:end], " \t\r\n:"))
+				out = append(out, body[done:start]...)
+				out = append(out, quoted...)
+				done = end
+			}
+		}
+		valueDone()
+	}
+	if out == nil {
+		return body
+	}
+	return append(out, body[done:]...)
+}
+
+// skipValue reads one complete value (scalar, object or array) from dec.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func matchesKey(k string, keys []string) bool {
+	for _, want := range keys {
+		if strings.EqualFold(k, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// loginToken returns the auth token of a /auth/login response body, or "".
+// The Recorder and the Tracer use it to mask the token wherever it shows up
+// later, e.g. echoed in a header or an error message.
+func loginToken(respBody []byte) string {
+	var login struct {
+		User struct {
+			AuthToken string `json:"auth_token"`
+		} `json:"user"`
+	}
+	if json.Unmarshal(respBody, &login) != nil {
+		return ""
+	}
+	return login.User.AuthToken
+}
@@ -0,0 +1,59 @@
+package oms
+
+import "testing"
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{
+			name: "order and numbers kept",
+			body: `{"zeta": 1.50, "password": "p@ss", "alpha": 1e3, "id": 900719925474099312}`,
+			want: `{"zeta": 1.50, "password": "***", "alpha": 1e3, "id": 900719925474099312}`,
+		},
+		{
+			name: "nested and case-insensitive",
+			body: "{\n  \"user\": {\"Auth_Token\" : \"abc\", \"name\": \"x\"},\n  \"list\": [{\"password\":\"a\"}, {\"password\":null}]\n}",
+			want: "{\n  \"user\": {\"Auth_Token\" : \"***\", \"name\": \"x\"},\n  \"list\": [{\"password\":\"***\"}, {\"password\":\"***\"}]\n}",
+		},
+		{
+			name: "object value replaced whole",
+			body: `{"password": {"old": "a", "new": ["b"]}, "ok": true}`,
+			want: `{"password": "***", "ok": true}`,
+		},
+		{
+			name: "key-like values untouched",
+			body: `{"note": "password", "tags": ["password", {"k": "password"}]}`,
+			want: `{"note": "password", "tags": ["password", {"k": "password"}]}`,
+		},
+		{
+			name: "duplicate keys",
+			body: `{"password": "a", "password": "b"}`,
+			want: `{"password": "***", "password": "***"}`,
+		},
+		{name: "not json", body: `password=secret`, want: `password=secret`},
+		{name: "truncated json", body: `{"password": "sec`, want: `{"password": "sec`},
+		{name: "empty", body: ``, want: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactJSON([]byte(tt.body), "***", "password", "auth_token")); got != tt.want {
+				t.Errorf("RedactJSON:\n got  %s\n want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginToken(t *testing.T) {
+	tests := []struct{ body, want string }{
+		{`{"user": {"auth_token": "tok-1", "name": "x"}}`, "tok-1"},
+		{`{"user": {}}`, ""},
+		{`{"status": false, "message": "Invalid credentials"}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := loginToken([]byte(tt.body)); got != tt.want {
+			t.Errorf("loginToken(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package oms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/utils"
+)
+
+// RetryPolicy controls how often a failed OMS call is repeated and how long
+// the client waits in between. Delays grow exponentially from BaseDelay,
+// are capped at MaxDelay and carry random jitter so retries don't line up.
+type RetryPolicy struct {
+	MaxAttempts int // total attempts including the first; <= 1 disables retries
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy returns the policy configured in package config.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: config.RetryMaxAttempts,
+		BaseDelay:   time.Duration(config.RetryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(config.RetryMaxDelay) * time.Millisecond,
+	}
+}
+
+// backoff returns the wait before attempt n+1, after n failed attempts:
+// half the exponential delay plus up to the same again at random.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable classifies the outcome of one attempt. Network errors, per-call
+// timeouts, 5xx and 429 are worth repeating; other 4xx responses are
+// validation errors that will fail the same way again. The returned duration
+// is the server-requested delay from a Retry-After header, if any.
+func retryable(ctx context.Context, resp rawResponse, err error) (bool, time.Duration) {
+	if ctx.Err() != nil {
+		return false, 0
+	}
+	if err != nil {
+		var perm permanentError
+		return !errors.Is(err, ErrAuth) && !errors.As(err, &perm), 0
+	}
+	switch {
+	case resp.Status == http.StatusTooManyRequests:
+		return true, retryAfter(resp.Header)
+	case resp.Status >= 500:
+		return true, retryAfter(resp.Header)
+	}
+	return false, 0
+}
+
+// retrySubmit is retryable for submits, which are not idempotent: a
+// repeat is only safe when OMS provably never processed the first attempt —
+// the connection was never made, or OMS turned the request away with 429, or
+// with 503 and a Retry-After.
+func retrySubmit(ctx context.Context, resp rawResponse, err error) (bool, time.Duration) {
+	if ctx.Err() != nil {
+		return false, 0
+	}
+	if err != nil {
+		var perm permanentError
+		return notSent(err) && !errors.Is(err, ErrAuth) && !errors.As(err, &perm), 0
+	}
+	d := retryAfter(resp.Header)
+	switch {
+	case resp.Status == http.StatusTooManyRequests:
+		return true, d
+	case resp.Status == http.StatusServiceUnavailable && d > 0:
+		return true, d
+	}
+	return false, 0
+}
+
+// notSent reports whether err means the request never reached OMS:
+// dialling (DNS included) failed, or the client gave up before sending.
+func notSent(err error) bool {
+	var op *net.OpError
+	if errors.As(err, &op) && op.Op == "dial" {
+		return true
+	}
+	var ns notSentError
+	return errors.As(err, &ns)
+}
+
+// notSentError wraps a failure that happened before the request was sent.
+type notSentError struct{ err error }
+
+func (e notSentError) Error() string { return e.err.Error() }
+func (e notSentError) Unwrap() error { return e.err }
+
+// permanentError marks a failure withRetry must not repeat, such as a
+// re-login that already went through retries of its own.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so that retryable gives up on it.
+func permanent(err error) error { return permanentError{err} }
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date. It returns 0 when the header is absent or malformed.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryFunc decides whether an attempt is repeated and how long OMS asked us
+// to wait; see retryable and retrySubmit.
+type retryFunc func(ctx context.Context, resp rawResponse, err error) (bool, time.Duration)
+
+// withRetry runs attempt until it succeeds, should says to stop or the policy
+// runs out of attempts. The last response and error are returned unchanged.
+func (c *Client) withRetry(ctx context.Context, what string, should retryFunc, attempt func() (rawResponse, error)) (rawResponse, error) {
+	for n := 1; ; n++ {
+		resp, err := attempt()
+		retry, wait := should(ctx, resp, err)
+		if !retry || n >= c.Retry.MaxAttempts {
+			return resp, err
+		}
+
+		if b := c.Retry.backoff(n); b > wait {
+			wait = b
+		}
+		reason := "HTTP " + strconv.Itoa(resp.Status)
+		if err != nil {
+			reason = err.Error()
+		}
+		log.Printf("  ⟳ %s failed (%s) — retry %d/%d in %s",
+			what, reason, n, c.Retry.MaxAttempts-1, wait.Round(time.Millisecond))
+
+		if err := utils.SleepContext(ctx, wait); err != nil {
+			return resp, err
+		}
+	}
+}
+
+type attemptsKey struct{}
+
+// CountAttempts returns a context that tallies every HTTP attempt made by
+// Client calls using it (including retries and re-logins), together with a
+// function reporting the running total.
+func CountAttempts(ctx context.Context) (context.Context, func() int) {
+	n := new(atomic.Int64)
+	return context.WithValue(ctx, attemptsKey{}, n), func() int { return int(n.Load()) }
+}
+
+func countAttempt(ctx context.Context) {
+	if n, ok := ctx.Value(attemptsKey{}).(*atomic.Int64); ok {
+		n.Add(1)
+	}
+}
@@ -0,0 +1,67 @@
+package oms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		n      int
+		lo, hi time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{4, 400 * time.Millisecond, 800 * time.Millisecond},
+		{5, 500 * time.Millisecond, time.Second},  // capped
+		{80, 500 * time.Millisecond, time.Second}, // shift overflow
+	}
+	for _, tt := range tests {
+		for range 50 {
+			if d := p.backoff(tt.n); d < tt.lo || d > tt.hi {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.n, d, tt.lo, tt.hi)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("zero policy backoff = %s, want 0", d)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	withRetryAfter := http.Header{"Retry-After": {"7"}}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		resp  rawResponse
+		err   error
+		retry bool
+		wait  time.Duration
+	}{
+		{"network error", context.Background(), rawResponse{}, errors.New("connection reset"), true, 0},
+		{"auth error", context.Background(), rawResponse{}, fmt.Errorf("%w: re-login", ErrAuth), false, 0},
+		{"permanent error", context.Background(), rawResponse{}, permanent(errors.New("no recording")), false, 0},
+		{"cancelled", cancelled, rawResponse{}, context.Canceled, false, 0},
+		{"200", context.Background(), rawResponse{Status: 200}, nil, false, 0},
+		{"400", context.Background(), rawResponse{Status: 400}, nil, false, 0},
+		{"429", context.Background(), rawResponse{Status: 429, Header: withRetryAfter}, nil, true, 7 * time.Second},
+		{"503", context.Background(), rawResponse{Status: 503}, nil, true, 0},
+		{"503 after cancel", cancelled, rawResponse{Status: 503}, nil, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, wait := retryable(tt.ctx, tt.resp, tt.err)
+			if retry != tt.retry || wait != tt.wait {
+				t.Errorf("retryable = %v, %s; want %v, %s", retry, wait, tt.retry, tt.wait)
+			}
+		})
+	}
+}
@@ -1,156 +1,218 @@
 package oms
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"iter"
 	"log"
-	"time"
+	"net/http"
 
-	"oms-automtion/config"
 	"oms-automtion/models"
 )
 
-// FetchPendingOutages fetches all pending outages using pagination.
-// If limit > 0, it stops fetching once the limit is reached.
-func (c *Client) FetchPendingOutages(limit int) ([]models.Outage, error) {
-	var all []models.Outage
-	offset := 0
-
-	for {
-		// Stop if we have enough outages
-		if limit > 0 && len(all) >= limit {
-			break
-		}
-
-		// Revert to the known working endpoint and payload
-		url := fmt.Sprintf("%s/reason/pending", config.BaseURL)
-		reqBody := models.PendingRequest{
-			FilteredData: []models.FilteredData{},
-			Offset:       offset,
-			Limit:        config.PageSize,
-		}
-
-		body, _ := json.Marshal(reqBody)
-		req, err := c.NewAPIRequest("POST", url, body)
+// PendingOutages streams pending outages matching filter, one page at a
+// time. The next page is only requested once the consumer has taken every
+// outage of the previous one, so processing can start as soon as the first
+// page arrives. A page that still fails after retries is yielded as an error
+// and ends the sequence; outages already yielded are unaffected.
+//
+// Outages are also checked against filter here, and those outside it are
+// skipped: the filteredData format is not confirmed against a real OMS, and
+// a server that ignores it must not widen the run to every pending outage.
+//
+// Submitting a reason drops an outage off OMS's pending list, which shifts
+// later pages forward. The next offset is therefore pulled back by the number
+// of reasons this client submitted meanwhile, and no outage is yielded twice.
+func (c *Client) PendingOutages(ctx context.Context, filter models.PendingFilter) iter.Seq2[models.Outage, error] {
+	return func(yield func(models.Outage, error) bool) {
+		filtered, err := FilterData(filter)
 		if err != nil {
-			return nil, err
+			yield(models.Outage{}, err)
+			return
 		}
 
-		resp, err := c.HTTPClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("fetch pending: %w", err)
-		}
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		seen := map[string]bool{}
+		offset := 0
+		for {
+			pr, err := c.fetchPendingPage(ctx, filtered, offset)
+			if err != nil {
+				yield(models.Outage{}, err)
+				return
+			}
+			log.Printf("  [Fetch] offset=%d got=%d total=%d", offset, len(pr.Data), pr.TotalRecords)
 
-		if resp.StatusCode != 200 {
-			return nil, fmt.Errorf("pending returned %d: %s", resp.StatusCode, respBody)
-		}
+			submitsBefore := c.submits.Load()
+			outside := 0
+			for _, o := range pr.Data {
+				if seen[o.ID] {
+					continue
+				}
+				seen[o.ID] = true
+				if !MatchesFilter(o, filtered) {
+					outside++
+					continue
+				}
+				if !yield(o, nil) {
+					return
+				}
+			}
 
-		// DEBUG: Print raw response for first page
-		// if offset == 0 {
-		// 	log.Printf("\n[DEBUG] Raw API Response (first page):\n%s\n", string(respBody))
-		// }
+			if outside > 0 {
+				log.Printf("  [WARN] OMS returned %d outages outside the filter at offset %d; skipped them", outside, offset)
+			}
 
-		var pr models.PendingResponse
-		if err := json.Unmarshal(respBody, &pr); err != nil {
-			return nil, fmt.Errorf("unmarshal pending: %w", err)
+			if len(pr.Data) == 0 || offset+len(pr.Data) >= pr.TotalRecords {
+				return
+			}
+			offset += len(pr.Data) - int(c.submits.Load()-submitsBefore)
 		}
+	}
+}
 
-		all = append(all, pr.Data...)
-		log.Printf("  [Fetch] offset=%d got=%d total=%d", offset, len(pr.Data), pr.TotalRecords)
+// fetchPendingPage requests one page of /reason/pending.
+func (c *Client) fetchPendingPage(ctx context.Context, filtered []models.FilteredData, offset int) (*models.PendingResponse, error) {
+	url := fmt.Sprintf("%s/reason/pending", c.Profile.BaseURL)
+	reqBody := models.PendingRequest{
+		FilteredData: filtered,
+		Offset:       offset,
+		Limit:        c.Profile.PageSize,
+	}
 
-		// Re-enabled pagination logic
-		if(limit > 0 && len(all) >= limit) || offset+config.PageSize >= pr.TotalRecords || len(pr.Data) == 0 {
-			break
-		}
-		offset += config.PageSize
+	body, _ := json.Marshal(reqBody)
+	status, respBody, err := c.do(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pending (offset %d): %w", offset, err)
+	}
 
-		// Rate limiting: delay between pagination requests
-		time.Sleep(time.Duration(config.DelayBetweenPages) * time.Millisecond)
+	if status != 200 {
+		return nil, fmt.Errorf("offset %d: %w", offset, newAPIError("pending", url, rawResponse{Status: status, Body: respBody}))
 	}
-	
-	// Trim to exact limit if we over-fetched
-	if limit > 0 && len(all) > limit {
-		all = all[:limit]
+
+	var pr models.PendingResponse
+	if err := json.Unmarshal(respBody, &pr); err != nil {
+		return nil, fmt.Errorf("unmarshal pending (offset %d): %w", offset, err)
 	}
-	
-	return all, nil
+	return &pr, nil
 }
 
-// FetchLocIDs extracts loc_ids from the GeoJSON response for a specific outage.
-func (c *Client) FetchLocIDs(outageID string, feederID int) ([]int, error) {
-	url := fmt.Sprintf("%s/reason/%d/%s", config.BaseURL, feederID, outageID)
-	
+// FetchPendingOutages collects up to limit pending outages matching filter
+// (limit <= 0 means all). If a page fails, the outages from the pages
+// already fetched are returned together with the error.
+func (c *Client) FetchPendingOutages(ctx context.Context, limit int, filter models.PendingFilter) ([]models.Outage, error) {
+	var all []models.Outage
+	for o, err := range Take(c.PendingOutages(ctx, filter), limit) {
+		if err != nil {
+			return all, err
+		}
+		all = append(all, o)
+	}
+	return all, nil
+}
 
-	req, err := c.NewAPIRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// Take ends seq after n outages (n <= 0: no limit) without pulling the next
+// one, so no extra page is fetched once the limit is reached. Errors are
+// passed through and don't count towards n.
+func Take(seq iter.Seq2[models.Outage, error], n int) iter.Seq2[models.Outage, error] {
+	if n <= 0 {
+		return seq
+	}
+	return func(yield func(models.Outage, error) bool) {
+		taken := 0
+		for o, err := range seq {
+			if !yield(o, err) {
+				return
+			}
+			if err == nil {
+				if taken++; taken >= n {
+					return
+				}
+			}
+		}
 	}
+}
+
+// FetchOutageDetail fetches and decodes the reason detail of an outage: the
+// typed outage data, the feeder topology and the HT pole IDs.
+func (c *Client) FetchOutageDetail(ctx context.Context, outageID string, feederID int) (*models.OutageDetail, error) {
+	url := fmt.Sprintf("%s/reason/%d/%s", c.Profile.BaseURL, feederID, outageID)
 
-	resp, err := c.HTTPClient.Do(req)
+	status, respBody, err := c.do(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("fetch detail %s: %w", outageID, err)
 	}
-	respBody, _ := io.ReadAll(resp.Body)
-	resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("detail %s returned %d: %s", outageID, resp.StatusCode, respBody)
+	if status != 200 {
+		return nil, newAPIError("detail", url, rawResponse{Status: status, Body: respBody})
 	}
 
-	var detail models.ReasonDetailResponse
-	if err := json.Unmarshal(respBody, &detail); err != nil {
+	var resp models.ReasonDetailResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
 		return nil, fmt.Errorf("unmarshal detail %s: %w", outageID, err)
 	}
 
-	// Walk: feederPointGeoJson[*][*].row_to_json.features[*].properties.id
-	// Filter: only select poles where hlt == "HT Pole"
-	// Note: feederPointGeoJson contains mixed types (arrays and objects), so we parse each element
-	var locIDs []int
-	for _, rawElem := range detail.Data.FeederPointGeoJson {
-		// Try to unmarshal as array of RowToJSONWrapper
-		var wrappers []models.RowToJSONWrapper
-		if err := json.Unmarshal(rawElem, &wrappers); err != nil {
-			// Skip non-array elements (like the metadata object)
-			continue
-		}
-		
-		// Extract pole IDs from this array
-		for _, wrapper := range wrappers {
-			for _, feat := range wrapper.RowToJSON.Features {
-				// Only include HT Poles
-				if feat.Properties.Hlt == "HT Pole" && feat.Properties.ID != 0 {
-					locIDs = append(locIDs, feat.Properties.ID)
-				}
-			}
-		}
+	// outageData only feeds the log facts and verify; a shape we don't know
+	// must not cost the outage its reason, so keep it raw and carry on.
+	detail := &models.OutageDetail{}
+	if detail.Data, err = decodeOutageData(resp.Data.OutageData); err != nil {
+		log.Printf("    ⚠ detail %s: outageData not decoded, kept raw in Extra: %v", outageID, err)
+		detail.Data = models.OutageData{Extra: map[string]json.RawMessage{"outageData": resp.Data.OutageData}}
+	}
+
+	detail.Topology = ParseFeederTopology(resp.Data.FeederPointGeoJson)
+	detail.PoleIDs = HTPoleIDs(detail.Topology)
+	for _, w := range detail.Topology.Warnings {
+		log.Printf("    ⚠ detail %s: %s", outageID, w)
 	}
-	return locIDs, nil
+	return detail, nil
 }
 
-// SubmitReason posts the selected reason and location for an outage.
-func (c *Client) SubmitReason(outageID string, locID int, reasonID int) error {
-	url := fmt.Sprintf("%s/reason/outage/%s", config.BaseURL, outageID)
+// FetchLocIDs returns the HT pole IDs (valid loc_ids) of an outage's feeder.
+func (c *Client) FetchLocIDs(ctx context.Context, outageID string, feederID int) ([]int, error) {
+	detail, err := c.FetchOutageDetail(ctx, outageID, feederID)
+	if err != nil {
+		return nil, err
+	}
+	return detail.PoleIDs, nil
+}
 
-	payload := []models.ReasonPayloadItem{{LocID: locID, ReasonID: reasonID}}
-	body, _ := json.Marshal(payload)
+// SubmitReason posts the selected reason and location for an outage.
+func (c *Client) SubmitReason(ctx context.Context, outageID string, locID int, reasonID int) error {
+	return c.SubmitReasons(ctx, outageID, []models.ReasonPayloadItem{{LocID: locID, ReasonID: reasonID}})
+}
 
-	req, err := c.NewAPIRequest("POST", url, body)
-	if err != nil {
-		return err
+// SubmitReasons posts several loc_id/reason_id pairs for an outage in one
+// request. Use ValidatePayload first to check them against the feeder's poles.
+//
+// A submit is not idempotent, so it is only retried when OMS provably didn't
+// process it (see retrySubmit). A failure after the request may have reached
+// OMS — a dropped connection, a timeout, a 5xx — wraps ErrSubmitUnknown.
+func (c *Client) SubmitReasons(ctx context.Context, outageID string, items []models.ReasonPayloadItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("submit %s: no loc_id/reason_id pairs", outageID)
 	}
+	url := fmt.Sprintf("%s/reason/outage/%s", c.Profile.BaseURL, outageID)
 
-	resp, err := c.HTTPClient.Do(req)
+	body, _ := json.Marshal(items)
+
+	resp, err := c.withRetry(ctx, "POST "+url, retrySubmit, func() (rawResponse, error) {
+		return c.doAuthed(ctx, "POST", url, body)
+	})
 	if err != nil {
+		if !notSent(err) && !errors.Is(err, ErrAuth) {
+			return fmt.Errorf("submit %s: %w: %w", outageID, ErrSubmitUnknown, err)
+		}
 		return fmt.Errorf("submit %s: %w", outageID, err)
 	}
-	respBody, _ := io.ReadAll(resp.Body)
-	resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("submit %s returned %d: %s", outageID, resp.StatusCode, respBody)
+	if resp.Status != 200 {
+		apiErr := newAPIError("submit", url, resp)
+		if resp.Status >= 500 && !(resp.Status == http.StatusServiceUnavailable && retryAfter(resp.Header) > 0) {
+			return fmt.Errorf("%w: %w", ErrSubmitUnknown, apiErr)
+		}
+		return apiErr
 	}
+	c.submits.Add(1)
 	return nil
 }
@@ -0,0 +1,200 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+)
+
+// pendingServer serves a pending list that shrinks as reasons are submitted,
+// the way OMS does.
+func pendingServer(t *testing.T, n int) *httptest.Server {
+	var (
+		mu      sync.Mutex
+		pending []models.Outage
+	)
+	for i := range n {
+		pending = append(pending, models.Outage{ID: fmt.Sprint(i)})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/reason/pending":
+			var req models.PendingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("pending request: %v", err)
+			}
+			lo, hi := min(req.Offset, len(pending)), min(req.Offset+req.Limit, len(pending))
+			json.NewEncoder(w).Encode(models.PendingResponse{TotalRecords: len(pending), Data: pending[lo:hi]})
+		case strings.HasPrefix(r.URL.Path, "/reason/outage/"):
+			id := strings.TrimPrefix(r.URL.Path, "/reason/outage/")
+			pending = slices.DeleteFunc(pending, func(o models.Outage) bool { return o.ID == id })
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestPendingOutagesSubmitsShrinkList(t *testing.T) {
+	tests := []struct {
+		name     string
+		submit   func(i int) bool
+		outages  int
+		pageSize int
+	}{
+		{"no submits", func(int) bool { return false }, 25, 10},
+		{"submit all", func(int) bool { return true }, 25, 10},
+		{"submit every other", func(i int) bool { return i%2 == 0 }, 25, 10},
+		{"submit first page only", func(i int) bool { return i < 10 }, 25, 10},
+		{"page size 1", func(i int) bool { return i%3 != 0 }, 7, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := pendingServer(t, tt.outages)
+			defer srv.Close()
+			c := &Client{
+				HTTPClient: srv.Client(),
+				Retry:      RetryPolicy{MaxAttempts: 1},
+				Profile:    config.Profile{BaseURL: srv.URL, PageSize: tt.pageSize},
+			}
+
+			var got []string
+			for o, err := range c.PendingOutages(context.Background(), models.PendingFilter{}) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, o.ID)
+				if tt.submit(len(got) - 1) {
+					if err := c.SubmitReason(context.Background(), o.ID, 1, 21); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			var want []string
+			for i := range tt.outages {
+				want = append(want, fmt.Sprint(i))
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("yielded %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSubmitRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		handle   func(w http.ResponseWriter, n int) // n counts submits, from 1
+		attempts int
+		received int  // submits the handler saw
+		unknown  bool // ErrSubmitUnknown expected
+		ok       bool
+	}{
+		{
+			name: "applied, then connection dropped",
+			handle: func(w http.ResponseWriter, n int) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			},
+			attempts: 1, received: 1, unknown: true,
+		},
+		{
+			name:     "500",
+			handle:   func(w http.ResponseWriter, n int) { w.WriteHeader(http.StatusInternalServerError) },
+			attempts: 1, received: 1, unknown: true,
+		},
+		{
+			name:     "503 without Retry-After",
+			handle:   func(w http.ResponseWriter, n int) { w.WriteHeader(http.StatusServiceUnavailable) },
+			attempts: 1, received: 1, unknown: true,
+		},
+		{
+			name: "429, then accepted",
+			handle: func(w http.ResponseWriter, n int) {
+				if n == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			},
+			attempts: 2, received: 2, ok: true,
+		},
+		{
+			name: "503 with Retry-After, then accepted",
+			handle: func(w http.ResponseWriter, n int) {
+				if n == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			},
+			attempts: 2, received: 2, ok: true,
+		},
+		{
+			name: "rejected",
+			handle: func(w http.ResponseWriter, n int) {
+				http.Error(w, `{"message": "Reason already submitted"}`, http.StatusBadRequest)
+			},
+			attempts: 1, received: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handle(w, int(received.Add(1)))
+			}))
+			defer srv.Close()
+			c := &Client{
+				HTTPClient: srv.Client(),
+				Retry:      RetryPolicy{MaxAttempts: 3},
+				Profile:    config.Profile{BaseURL: srv.URL},
+			}
+
+			ctx, attempts := CountAttempts(context.Background())
+			err := c.SubmitReason(ctx, "OUT-1", 11, 21)
+			if (err == nil) != tt.ok || errors.Is(err, ErrSubmitUnknown) != tt.unknown {
+				t.Errorf("SubmitReason = %v; want ok %v, unknown %v", err, tt.ok, tt.unknown)
+			}
+			if got := attempts(); got != tt.attempts {
+				t.Errorf("%d attempts, want %d", got, tt.attempts)
+			}
+			if got := int(received.Load()); got != tt.received {
+				t.Errorf("server saw %d submits, want %d", got, tt.received)
+			}
+		})
+	}
+}
+
+// A submit that never connected is retried and is a plain failure.
+func TestSubmitRetriesDialErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c := &Client{
+		HTTPClient: &http.Client{},
+		Retry:      RetryPolicy{MaxAttempts: 3},
+		Profile:    config.Profile{BaseURL: url},
+	}
+	ctx, attempts := CountAttempts(context.Background())
+	err := c.SubmitReason(ctx, "OUT-1", 11, 21)
+	if err == nil || errors.Is(err, ErrSubmitUnknown) {
+		t.Errorf("SubmitReason = %v, want a failure that is not ErrSubmitUnknown", err)
+	}
+	if got := attempts(); got != 3 {
+		t.Errorf("%d attempts, want 3", got)
+	}
+}
@@ -0,0 +1,135 @@
+package oms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"oms-automtion/config"
+)
+
+// TokenCache keeps one OMS auth token on disk, encrypted, so later runs and
+// CLI invocations can skip the login. A cached token is only handed out for
+// the same OMS base URL and employee number it was issued for.
+type TokenCache struct {
+	Path string
+	// Key encrypts the file. NewTokenCache derives it from the credentials,
+	// so changing the password also orphans the old token.
+	Key string
+}
+
+// cachedToken is the plaintext inside the cache file.
+type cachedToken struct {
+	BaseURL   string    `json:"base_url"`
+	EmpNo     string    `json:"emp_no"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewTokenCache returns the cache configured by config.TokenCachePath, or
+// nil if caching is off or there are no credentials to key it with.
+// OMS_TOKEN_CACHE_KEY overrides the derived key.
+func NewTokenCache() *TokenCache {
+	path := config.TokenCachePath()
+	creds := config.Creds()
+	if path == "" || creds.Password == "" {
+		return nil
+	}
+	key := os.Getenv("OMS_TOKEN_CACHE_KEY")
+	if key == "" {
+		key = "oms-token-cache\x00" + creds.EmpNo + "\x00" + creds.Password
+	}
+	return &TokenCache{Path: path, Key: key}
+}
+
+// Load returns the cached token for baseURL and empNo if it is still valid
+// for at least margin. A missing, foreign, expired or unreadable cache just
+// yields ok == false.
+func (tc *TokenCache) Load(baseURL, empNo string, margin time.Duration) (token string, expiresAt time.Time, ok bool) {
+	if tc == nil {
+		return "", time.Time{}, false
+	}
+	sealed, err := os.ReadFile(tc.Path)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	plain, err := config.Open(sealed, tc.Key)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	var ct cachedToken
+	if err := json.Unmarshal(plain, &ct); err != nil {
+		return "", time.Time{}, false
+	}
+	if ct.BaseURL != baseURL || ct.EmpNo != empNo || ct.Token == "" {
+		return "", time.Time{}, false
+	}
+	if time.Until(ct.ExpiresAt) <= margin {
+		return "", time.Time{}, false
+	}
+	return ct.Token, ct.ExpiresAt, true
+}
+
+// Store replaces the cached token.
+func (tc *TokenCache) Store(baseURL, empNo, token string, expiresAt time.Time) error {
+	if tc == nil {
+		return nil
+	}
+	plain, err := json.Marshal(cachedToken{BaseURL: baseURL, EmpNo: empNo, Token: token, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+	sealed, err := config.Seal(plain, tc.Key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tc.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := tc.Path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, tc.Path)
+}
+
+// Invalidate removes the cached token, e.g. after OMS rejected it.
+func (tc *TokenCache) Invalidate() error {
+	if tc == nil {
+		return nil
+	}
+	if err := os.Remove(tc.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// tokenExpiry reads the exp claim of a JWT. For anything else, or a JWT
+// without exp, it assumes the token lives for fallback from now.
+func tokenExpiry(token string, fallback time.Duration) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "=")); err == nil {
+			var claims struct {
+				Exp json.Number `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil {
+				if exp, err := claims.Exp.Float64(); err == nil && exp > 0 {
+					return time.Unix(int64(exp), 0), true
+				}
+			}
+		}
+	}
+	return time.Now().Add(fallback), false
+}
+
+// describeExpiry renders how long a token has left, for log lines.
+func describeExpiry(expiresAt time.Time) string {
+	return fmt.Sprintf("expires in %s", time.Until(expiresAt).Round(time.Second))
+}
@@ -0,0 +1,160 @@
+package oms
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oms-automtion/config"
+)
+
+// jwt returns an unsigned JWT whose payload is claims.
+func jwt(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(claims)) + ".sig"
+}
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		token   string
+		want    time.Time // zero: the fallback
+		fromJWT bool
+	}{
+		{"valid", jwt(fmt.Sprintf(`{"sub":"1","exp":%d}`, exp.Unix())), exp, true},
+		{"expired", jwt(`{"exp":1000}`), time.Unix(1000, 0), true},
+		{"no exp", jwt(`{"sub":"1"}`), time.Time{}, false},
+		{"payload not base64", "a.!!!.c", time.Time{}, false},
+		{"payload not json", "a." + base64.RawURLEncoding.EncodeToString([]byte("exp")) + ".c", time.Time{}, false},
+		{"opaque", "0123456789abcdef", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			got, fromJWT := tokenExpiry(tt.token, time.Hour)
+			if fromJWT != tt.fromJWT {
+				t.Errorf("fromJWT = %v, want %v", fromJWT, tt.fromJWT)
+			}
+			if tt.want.IsZero() {
+				if got.Before(before.Add(time.Hour)) || got.After(time.Now().Add(time.Hour)) {
+					t.Errorf("expiry = %s, want the 1h fallback", got)
+				}
+			} else if !got.Equal(tt.want) {
+				t.Errorf("expiry = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	tc := &TokenCache{Path: filepath.Join(t.TempDir(), "token"), Key: "k"}
+	exp := time.Now().Add(time.Hour)
+	if err := tc.Store("https://oms", "1", "tok", exp); err != nil {
+		t.Fatal(err)
+	}
+
+	if tok, _, ok := tc.Load("https://oms", "1", time.Minute); !ok || tok != "tok" {
+		t.Errorf("Load = %q, %v; want the stored token", tok, ok)
+	}
+	for _, miss := range []struct {
+		name      string
+		cache     *TokenCache
+		base, emp string
+		margin    time.Duration
+	}{
+		{"other OMS", tc, "https://training", "1", 0},
+		{"other employee", tc, "https://oms", "2", 0},
+		{"expires within margin", tc, "https://oms", "1", 2 * time.Hour},
+		{"wrong key", &TokenCache{Path: tc.Path, Key: "other"}, "https://oms", "1", 0},
+		{"nil cache", nil, "https://oms", "1", 0},
+	} {
+		if _, _, ok := miss.cache.Load(miss.base, miss.emp, miss.margin); ok {
+			t.Errorf("%s: Load hit, want a miss", miss.name)
+		}
+	}
+
+	if err := tc.Invalidate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := tc.Load("https://oms", "1", 0); ok {
+		t.Error("Load hit after Invalidate")
+	}
+	if err := tc.Invalidate(); err != nil {
+		t.Errorf("Invalidate without a cache file: %v", err)
+	}
+}
+
+// TestTokenCacheAfter401 checks that a cached token OMS rejects is dropped,
+// and replaced only if the re-login succeeds.
+func TestTokenCacheAfter401(t *testing.T) {
+	fresh := jwt(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(time.Hour).Unix()))
+	for _, tt := range []struct {
+		name      string
+		loginOK   bool
+		wantToken string // in the cache afterwards; "" = none
+	}{
+		{"re-login succeeds", true, fresh},
+		{"re-login fails", false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/auth/login" && tt.loginOK:
+					fmt.Fprintf(w, `{"user":{"auth_token":%q}}`, fresh)
+				case r.URL.Path == "/auth/login":
+					w.WriteHeader(http.StatusUnauthorized)
+				case r.Header.Get("Authorization") != "bearer "+fresh:
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer srv.Close()
+
+			empNo := config.Creds().EmpNo
+			tc := &TokenCache{Path: filepath.Join(t.TempDir(), "token"), Key: "k"}
+			if err := tc.Store(srv.URL, empNo, "revoked", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			c := &Client{
+				HTTPClient: srv.Client(),
+				Retry:      RetryPolicy{MaxAttempts: 1},
+				Profile:    config.Profile{BaseURL: srv.URL},
+				TokenCache: tc,
+			}
+			if err := c.Login(context.Background()); err != nil || c.Token != "revoked" {
+				t.Fatalf("Login = %v with token %q, want the cached token", err, c.Token)
+			}
+
+			_, _, err := c.do(context.Background(), "GET", srv.URL+"/api", nil)
+			if (err == nil) != tt.loginOK {
+				t.Errorf("do error = %v, want success %v", err, tt.loginOK)
+			}
+			tok, _, _ := tc.Load(srv.URL, empNo, 0)
+			if tok != tt.wantToken {
+				t.Errorf("cached token = %q, want %q", tok, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestLoginRejectsExpiredToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"user":{"auth_token":%q}}`, jwt(`{"exp":1000}`))
+	}))
+	defer srv.Close()
+	c := &Client{
+		HTTPClient: srv.Client(),
+		Retry:      RetryPolicy{MaxAttempts: 1},
+		Profile:    config.Profile{BaseURL: srv.URL},
+		Creds:      &config.Credentials{EmpNo: "1"},
+	}
+	if err := c.Login(context.Background()); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Login = %v, want an expired-token error", err)
+	}
+}
@@ -0,0 +1,260 @@
+package oms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"oms-automtion/models"
+)
+
+// HTPole is the properties.hlt value of poles a reason can be submitted on.
+const HTPole = "HT Pole"
+
+// ParseFeederTopology walks data.feederPointGeoJson and collects every
+// feature and metadata object in it. OMS mixes several shapes in that list:
+//
+//	[ {"row_to_json": FeatureCollection}, ... ]   arrays of wrapped collections
+//	{"row_to_json": FeatureCollection}            a wrapper on its own
+//	{"type": "FeatureCollection", "features": []} bare collections (also nested)
+//	{"type": "Feature", ...}                      bare features
+//	{"feeder_id": 102, "total_poles": 2, ...}     feeder metadata
+//
+// Anything else, and features without a usable ID, end up in Warnings.
+func ParseFeederTopology(elems []json.RawMessage) models.FeederTopology {
+	p := &topologyParser{}
+	for i, raw := range elems {
+		p.walk(fmt.Sprintf("feederPointGeoJson[%d]", i), raw)
+	}
+	return p.topo
+}
+
+type topologyParser struct {
+	topo models.FeederTopology
+}
+
+func (p *topologyParser) warnf(path, format string, args ...any) {
+	p.topo.Warnings = append(p.topo.Warnings, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (p *topologyParser) walk(path string, raw json.RawMessage) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return
+	}
+
+	switch raw[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			p.warnf(path, "malformed array: %v", err)
+			return
+		}
+		for i, elem := range list {
+			p.walk(fmt.Sprintf("%s[%d]", path, i), elem)
+		}
+	case '{':
+		p.walkObject(path, raw)
+	default:
+		p.warnf(path, "unexpected value %s", truncateRaw(raw))
+	}
+}
+
+func (p *topologyParser) walkObject(path string, raw json.RawMessage) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		p.warnf(path, "malformed object: %v", err)
+		return
+	}
+
+	if inner, ok := obj["row_to_json"]; ok {
+		p.walk(path+".row_to_json", inner)
+		return
+	}
+
+	var typ string
+	_ = json.Unmarshal(obj["type"], &typ)
+	switch typ {
+	case "FeatureCollection":
+		p.walk(path+".features", obj["features"])
+		return
+	case "Feature":
+		p.feature(path, obj)
+		return
+	}
+
+	if isFeederMetadata(obj) {
+		meta, err := decodeFeederMetadata(raw)
+		if err != nil {
+			p.warnf(path, "malformed feeder metadata: %v", err)
+			return
+		}
+		p.topo.Metadata = append(p.topo.Metadata, meta)
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if typ != "" {
+		p.warnf(path, "unknown GeoJSON type %q", typ)
+		return
+	}
+	p.warnf(path, "unknown object with keys [%s]", strings.Join(keys, ", "))
+}
+
+func (p *topologyParser) feature(path string, obj map[string]json.RawMessage) {
+	var props map[string]json.RawMessage
+	_ = json.Unmarshal(obj["properties"], &props)
+
+	id, ok := parseID(props["id"])
+	if !ok {
+		p.warnf(path, "feature without a numeric properties.id (id=%s)", truncateRaw(props["id"]))
+		return
+	}
+
+	f := models.FeederFeature{ID: id}
+	_ = json.Unmarshal(props["hlt"], &f.Type)
+	f.Type = strings.TrimSpace(f.Type)
+	if f.Type == "" {
+		f.Type = "unknown"
+	}
+	_ = json.Unmarshal(obj["loc_str"], &f.LocStr)
+
+	var geom struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	_ = json.Unmarshal(obj["geometry"], &geom)
+	f.GeometryType = geom.Type
+	// Only points have a single coordinate pair; lines and polygons are kept
+	// as features without coordinates.
+	if geom.Type == "Point" {
+		var coords []float64
+		if err := json.Unmarshal(geom.Coordinates, &coords); err == nil {
+			f.Coordinates = coords
+		}
+	}
+
+	p.topo.Features = append(p.topo.Features, f)
+}
+
+// isFeederMetadata reports whether obj looks like the feeder summary object
+// OMS appends to feederPointGeoJson.
+func isFeederMetadata(obj map[string]json.RawMessage) bool {
+	for _, k := range []string{"feeder_id", "feeder_name", "total_poles"} {
+		if _, ok := obj[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseID accepts an ID sent as a JSON number or a numeric string.
+func parseID(raw json.RawMessage) (int, bool) {
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, false
+		}
+		n = json.Number(strings.TrimSpace(s))
+	}
+	id, err := strconv.Atoi(n.String())
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// truncateRaw renders raw for a warning, cut to about 40 bytes on a rune
+// boundary so Gujarati feeder names aren't split mid-character.
+func truncateRaw(raw json.RawMessage) string {
+	s := string(raw)
+	if s == "" {
+		return "missing"
+	}
+	if len(s) > 40 {
+		cut := 37
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		return s[:cut] + "..."
+	}
+	return s
+}
+
+// HTPoleIDs returns the IDs of the HT poles in t, in order and without
+// duplicates. These are the valid loc_ids for a reason.
+func HTPoleIDs(t models.FeederTopology) []int {
+	var ids []int
+	seen := map[int]bool{}
+	for _, f := range t.Features {
+		if !strings.EqualFold(f.Type, HTPole) || seen[f.ID] {
+			continue
+		}
+		seen[f.ID] = true
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+// DescribeTopology summarises what t contains, for notes on outages that have
+// no HT pole: e.g. "no HT poles; found 1 LT Pole; metadata total_poles=2".
+func DescribeTopology(t models.FeederTopology) string {
+	counts := map[string]int{}
+	var types []string
+	for _, f := range t.Features {
+		if counts[f.Type] == 0 {
+			types = append(types, f.Type)
+		}
+		counts[f.Type]++
+	}
+
+	parts := []string{"no HT poles"}
+	if len(types) == 0 {
+		parts = append(parts, "no features")
+	} else {
+		found := make([]string, 0, len(types))
+		for _, typ := range types {
+			found = append(found, fmt.Sprintf("%d %s", counts[typ], typ))
+		}
+		parts = append(parts, "found "+strings.Join(found, ", "))
+	}
+	for _, m := range t.Metadata {
+		parts = append(parts, fmt.Sprintf("metadata total_poles=%d", m.TotalPoles))
+	}
+	if n := len(t.Warnings); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d unparsed element(s): %s", n, t.Warnings[0]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ValidatePayload checks items before they are submitted for an outage:
+// there must be at least one pair, every loc_id must be one of poleIDs (the
+// feeder's HT poles) and no loc_id may appear twice.
+func ValidatePayload(items []models.ReasonPayloadItem, poleIDs []int) error {
+	if len(items) == 0 {
+		return fmt.Errorf("no loc_id/reason_id pairs")
+	}
+	seen := map[int]bool{}
+	for _, it := range items {
+		if it.ReasonID <= 0 {
+			return fmt.Errorf("loc_id %d: invalid reason_id %d", it.LocID, it.ReasonID)
+		}
+		if !slices.Contains(poleIDs, it.LocID) {
+			return fmt.Errorf("loc_id %d is not an HT pole of this feeder (%d poles)", it.LocID, len(poleIDs))
+		}
+		if seen[it.LocID] {
+			return fmt.Errorf("loc_id %d appears more than once", it.LocID)
+		}
+		seen[it.LocID] = true
+	}
+	return nil
+}
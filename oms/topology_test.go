@@ -0,0 +1,88 @@
+package oms
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"oms-automtion/models"
+)
+
+func TestParseFeederTopology(t *testing.T) {
+	point := `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [72.9, 21.2]}, "loc_str": "F/1", "properties": {"hlt": "HT Pole", "id": 11}}`
+	line := `{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[72.9, 21.2], [73.0, 21.3]]}, "properties": {"hlt": "HT Line", "id": 12}}`
+	polygon := `{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[72.9, 21.2], [73.0, 21.3], [72.9, 21.2]]]}, "properties": {"hlt": "Transformer", "id": 13}}`
+
+	tests := []struct {
+		name     string
+		elems    string
+		features []models.FeederFeature
+		warnings int
+	}{
+		{
+			name:  "point",
+			elems: `[` + point + `]`,
+			features: []models.FeederFeature{
+				{ID: 11, Type: "HT Pole", LocStr: "F/1", GeometryType: "Point", Coordinates: []float64{72.9, 21.2}},
+			},
+		},
+		{
+			name:     "line",
+			elems:    `[` + line + `]`,
+			features: []models.FeederFeature{{ID: 12, Type: "HT Line", GeometryType: "LineString"}},
+		},
+		{
+			name:     "polygon",
+			elems:    `[` + polygon + `]`,
+			features: []models.FeederFeature{{ID: 13, Type: "Transformer", GeometryType: "Polygon"}},
+		},
+		{
+			name:  "empty",
+			elems: `[null, [], {"row_to_json": {"type": "FeatureCollection", "features": []}}]`,
+		},
+		{
+			name: "nested collections",
+			elems: `[[{"row_to_json": {"type": "FeatureCollection", "features": [` + point + `,
+				{"type": "FeatureCollection", "features": [` + line + `]}]}}],
+				{"feeder_id": 1, "total_poles": 2}, {"foo": 1}]`,
+			features: []models.FeederFeature{
+				{ID: 11, Type: "HT Pole", LocStr: "F/1", GeometryType: "Point", Coordinates: []float64{72.9, 21.2}},
+				{ID: 12, Type: "HT Line", GeometryType: "LineString"},
+			},
+			warnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var elems []json.RawMessage
+			if err := json.Unmarshal([]byte(tt.elems), &elems); err != nil {
+				t.Fatal(err)
+			}
+			topo := ParseFeederTopology(elems)
+			if !reflect.DeepEqual(topo.Features, tt.features) {
+				t.Errorf("features = %+v, want %+v", topo.Features, tt.features)
+			}
+			if len(topo.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", topo.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestTruncateRaw(t *testing.T) {
+	tests := []struct{ raw, want string }{
+		{"", "missing"},
+		{`{"id": 11}`, `{"id": 11}`},
+		{`"` + strings.Repeat("a", 50) + `"`, `"` + strings.Repeat("a", 36) + "..."},
+		// Each Gujarati letter is 3 bytes; byte 37 falls inside one.
+		{`"x` + strings.Repeat("ક", 20) + `"`, `"x` + strings.Repeat("ક", 11) + "..."},
+	}
+	for _, tt := range tests {
+		got := truncateRaw(json.RawMessage(tt.raw))
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateRaw(%s) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package oms
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Trace modes for Client.UseTrace.
+const (
+	TraceOff    = ""
+	TraceOn     = "on"     // one line per request and response
+	TraceBodies = "bodies" // plus the (redacted, truncated) bodies
+)
+
+// traceMaxBody caps each traced body; detail responses carry whole feeder
+// geometries.
+const traceMaxBody = 4096
+
+// Tracer is an http.RoundTripper that logs every OMS exchange to Out:
+// method, path, status, latency and sizes, and with Bodies the bodies too.
+// Auth tokens, passwords and employee numbers never reach the trace.
+type Tracer struct {
+	Base   http.RoundTripper
+	Out    io.Writer
+	Bodies bool
+
+	mu    sync.Mutex
+	seq   int
+	token string // last token seen in a login response
+}
+
+// ParseTraceMode checks a -trace / OMS_TRACE value.
+func ParseTraceMode(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", "off", "0", "false":
+		return TraceOff, nil
+	case TraceOn, "1", "true", "headers":
+		return TraceOn, nil
+	case TraceBodies:
+		return TraceBodies, nil
+	}
+	return "", fmt.Errorf("unknown trace mode %q (want on or bodies)", s)
+}
+
+// UseTrace makes c log its traffic to out in the given mode. It wraps the
+// current transport, so cassette traffic is traced as well.
+func (c *Client) UseTrace(mode string, out io.Writer) {
+	if mode == TraceOff || out == nil {
+		return
+	}
+	c.HTTPClient = &http.Client{Transport: &Tracer{
+		Base:   transportOf(c.HTTPClient),
+		Out:    out,
+		Bodies: mode == TraceBodies,
+	}}
+}
+
+func (t *Tracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	t.mu.Lock()
+	t.seq++
+	n := t.seq
+	t.logf("→ #%d %s %s (%s)", n, req.Method, req.URL.RequestURI(), byteSize(len(reqBody)))
+	if t.Bodies {
+		t.logf("  authorization: %s", t.redactAuth(req.Header.Get("Authorization")))
+		t.body(reqBody)
+	}
+	t.mu.Unlock()
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.mu.Lock()
+		t.logf("← #%d %s %s failed after %s: %v", n, req.Method, req.URL.Path, elapsed, err)
+		t.mu.Unlock()
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tok := loginToken(respBody); tok != "" {
+		t.token = tok
+	}
+
+	t.logf("← #%d %d %s %s %s (%s)", n, resp.StatusCode, req.Method, req.URL.Path, elapsed, byteSize(len(respBody)))
+	if t.Bodies {
+		t.body(respBody)
+	}
+	return resp, nil
+}
+
+// logf writes one timestamped trace line. Callers must hold t.mu.
+func (t *Tracer) logf(format string, args ...any) {
+	fmt.Fprintf(t.Out, "%s %s\n", time.Now().Format("2006/01/02 15:04:05.000"), fmt.Sprintf(format, args...))
+}
+
+// body writes a redacted, truncated body. Callers must hold t.mu.
+func (t *Tracer) body(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	s := string(RedactJSON(b, redactedSecret, "password", "empNo", "emp_no"))
+	s = string(RedactJSON([]byte(s), redactedToken, "auth_token", "token"))
+	if t.token != "" {
+		s = strings.ReplaceAll(s, t.token, redactedToken)
+	}
+	t.logf("  %s", truncateBody(s, traceMaxBody))
+}
+
+// truncateBody cuts s to at most max bytes, backing up to the start of a
+// rune so multi-byte text such as Gujarati names isn't split mid-character.
+func truncateBody(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("… [%d more bytes]", len(s)-cut)
+}
+
+func (t *Tracer) redactAuth(v string) string {
+	scheme, token, ok := strings.Cut(v, " ")
+	if !ok || token == "null" {
+		return v
+	}
+	return scheme + " " + redactedToken
+}
+
+func byteSize(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
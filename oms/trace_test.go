@@ -0,0 +1,38 @@
+package oms
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateBody(t *testing.T) {
+	gu := "ગુજરાત" // 3-byte runes
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"exact", "abcde", 5, "abcde"},
+		{"ascii", "abcdefg", 5, "abcde… [2 more bytes]"},
+		{"on a rune boundary", gu, 6, "ગુ… [12 more bytes]"},
+		{"inside a rune", gu, 7, "ગુ… [12 more bytes]"},
+		{"inside the first rune", gu, 2, "… [18 more bytes]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateBody(tt.s, tt.max)
+			if got != tt.want {
+				t.Errorf("truncateBody(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateBody(%q, %d) is not valid UTF-8", tt.s, tt.max)
+			}
+			if kept, _, _ := strings.Cut(got, "… ["); len(kept) > tt.max {
+				t.Errorf("kept %d bytes, max %d", len(kept), tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package oms
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"oms-automtion/config"
+)
+
+// TransportInfo describes what NewHTTPClient loaded, for diagnostics.
+type TransportInfo struct {
+	ExtraCAs          int       `json:"extra_cas"` // certificates added from the CA file
+	ClientCertSubject string    `json:"client_cert_subject,omitempty"`
+	ClientCertExpiry  time.Time `json:"client_cert_expiry,omitzero"`
+}
+
+var (
+	httpClientMu sync.RWMutex
+	httpClient   = http.DefaultClient
+)
+
+// UseTransport builds the HTTP client for t and makes every later NewClient
+// use it. Call it once at startup; errors mean t can't work.
+func UseTransport(t config.Transport) (*TransportInfo, error) {
+	hc, info, err := NewHTTPClient(t)
+	if err != nil {
+		return nil, err
+	}
+	httpClientMu.Lock()
+	httpClient = hc
+	httpClientMu.Unlock()
+	return info, nil
+}
+
+func defaultHTTPClient() *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return httpClient
+}
+
+// NewHTTPClient returns an HTTP client with t's proxy, trust roots, client
+// certificate, timeouts and pool sizes.
+func NewHTTPClient(t config.Transport) (*http.Client, *TransportInfo, error) {
+	if err := t.Validate(); err != nil {
+		return nil, nil, err
+	}
+	info := &TransportInfo{}
+	tlsConf := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if t.CAFile != "" {
+		data, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for rest := data; ; {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if block.Type != "CERTIFICATE" || err != nil {
+				continue
+			}
+			pool.AddCert(cert)
+			info.ExtraCAs++
+		}
+		if info.ExtraCAs == 0 {
+			return nil, nil, fmt.Errorf("CA file %s: no PEM certificates found", t.CAFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if t.ClientCert != "" {
+		pair, err := tls.LoadX509KeyPair(t.ClientCert, t.ClientKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("client certificate: %w", err)
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("client certificate: %w", err)
+		}
+		if time.Now().After(leaf.NotAfter) {
+			return nil, nil, fmt.Errorf("client certificate %s expired on %s", leaf.Subject, leaf.NotAfter.Format(time.DateOnly))
+		}
+		info.ClientCertSubject = leaf.Subject.String()
+		info.ClientCertExpiry = leaf.NotAfter
+		tlsConf.Certificates = []tls.Certificate{pair}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	switch t.Proxy {
+	case "":
+	case "direct":
+		proxy = nil
+	default:
+		u, err := url.Parse(t.Proxy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("proxy: %w", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	dialer := &net.Dialer{Timeout: t.ConnectTimeout, KeepAlive: 30 * time.Second}
+	tr := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConf,
+		TLSHandshakeTimeout:   t.ConnectTimeout,
+		ResponseHeaderTimeout: t.ResponseTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          t.MaxIdleConns,
+		MaxIdleConnsPerHost:   t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       t.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &http.Client{Transport: tr}, info, nil
+}
+
+// ProxyFor reports which proxy the client uses to reach rawURL: a URL,
+// "direct" or an error message.
+func ProxyFor(hc *http.Client, rawURL string) string {
+	tr, ok := hc.Transport.(*http.Transport)
+	if !ok || tr.Proxy == nil {
+		return "direct"
+	}
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return err.Error()
+	}
+	u, err := tr.Proxy(req)
+	if err != nil {
+		return err.Error()
+	}
+	if u == nil {
+		return "direct"
+	}
+	return u.Redacted()
+}
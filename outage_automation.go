@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"math/rand"
 	"os"
+	"os/signal"
+	"slices"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 	_ "time/tzdata"
 
@@ -17,55 +24,8 @@ import (
 	"oms-automtion/utils"
 )
 
-/*
-╔════════════════════════════════════════════════════════════════════════════╗
-║                         REASON ID REFERENCE TABLE                          ║
-╠═════╦══════════════════════════════════════════════════════════════════════╣
-║ ID  ║ Reason Name                                                          ║
-╠═════╬══════════════════════════════════════════════════════════════════════╣
-║  1  ║ Pin Puncture                                                         ║
-║  2  ║ Pole collapse                                                        ║
-║  4  ║ Animal Fault                                                         ║
-║  5  ║ Bird Fault                                                           ║
-║  6  ║ Cable Wire Fault                                                     ║
-║  7  ║ Vehicle accident                                                     ║
-║  8  ║ Conductor Slipped From Pin Insulator                                 ║
-║  9  ║ Conductor Snapped HT Line                                            ║
-║ 10  ║ Disaster like heavy rain                                             ║
-║ 12  ║ Flash over of Breakers                                               ║
-║ 15  ║ Guarding Fault                                                       ║
-║ 16  ║ Hoarding Fallen                                                      ║
-║ 17  ║ HT Connection Internal Fault                                         ║
-║ 18  ║ Insulation Burnt                                                     ║
-║ 19  ║ Insulator Puncture                                                   ║
-║ 20  ║ Jumper Burnt                                                         ║
-║ 21  ║ Jumper Touching                                                      ║
-║ 22  ║ LA Fault                                                             ║
-║ 23  ║ Lightening Stroke                                                    ║
-║ 24  ║ Low Clearance at Crossing                                            ║
-║ 25  ║ No Cause found                                                       ║
-║ 27  ║ Overhead ABC conductor fault                                         ║
-║ 28  ║ Relay Problems                                                       ║
-║ 29  ║ Shakle Puncture                                                     ║
-║ 30  ║ Transformer Failure                                                  ║
-║ 31  ║ Tree / Tree Branch Falling                                           ║
-║ 32  ║ Under ground cable fault                                             ║
-║ 33  ║ Under Ground Cable Fault by Outsider                                 ║
-║ 65  ║ Smoke                                                                ║
-║ 74  ║ Cyclone                                                              ║
-║ 75  ║ Accident                                                             ║
-║ 78  ║ Jumper                                                               ║
-║ 86  ║ Danger to life                                                       ║
-║ 87  ║ Bomb blast                                                           ║
-║ 88  ║ Air Strike                                                           ║
-║ 91  ║ Fire in buildings                                                    ║
-║ 92  ║ Fire in godown                                                       ║
-║ 93  ║ Fire in fiels/jungle                                                 ║
-║ 100 ║ DO Fuse short with MS angle                                          ║
-║ 112 ║ Line Fabrication Damage                                              ║
-║ 113 ║ Heavy Wind                                                           ║
-╚═════╩══════════════════════════════════════════════════════════════════════╝
-*/
+// The OMS reason IDs (names in English and Gujarati, plus a category) live
+// in config/reasons.json; see config.LookupReason.
 
 // ProcessedRow is one row in the result table returned by RunAutomation.
 type ProcessedRow struct {
@@ -73,57 +33,132 @@ type ProcessedRow struct {
 	Hours    float64 `json:"hours"`
 	Bucket   string  `json:"bucket"`
 	Feeder   string  `json:"feeder"`
+	Fault    string  `json:"fault,omitempty"` // fault location from the outage detail
 	ReasonID int     `json:"reason_id"`
-	Status   string  `json:"status"` // "submitted" | "skipped" | "failed" | "parse_error"
+	Reason   string  `json:"reason_name,omitempty"`
+	LocIDs   []int   `json:"loc_ids,omitempty"`  // poles the reason was submitted on
+	Attempts int     `json:"attempts,omitempty"` // HTTP attempts incl. retries
+	Status   string  `json:"status"`             // "submitted" | "skipped" | "manual_review" | "failed" | "parse_error" | "not_attempted" | "unknown"
 	Note     string  `json:"note,omitempty"`
+	// Cause is the failure category of a failed row, see oms.Cause.
+	Cause string `json:"cause,omitempty"`
+	// Verification is set for submitted rows when RunOptions.Verify is on:
+	// "verified" | "unverified" | "still_pending".
+	Verification string `json:"verification,omitempty"`
 }
 
 // RunResult is what the HTTP /run endpoint returns and what the CLI prints.
 type RunResult struct {
-	Total      int            `json:"total"`
-	Success    int            `json:"success"`
-	Failed     int            `json:"failed"`
-	Skipped    int            `json:"skipped"`
+	Profile string `json:"profile"`          // OMS environment the run talked to
+	Replay  bool   `json:"replay,omitempty"` // outages came from a cassette, not OMS
+	// RulesSource is the rules file the run classified with, or "built-in";
+	// RulesWarning is set when the last reload of that file was rejected.
+	RulesSource  string `json:"rules_source"`
+	RulesWarning string `json:"rules_warning,omitempty"`
+	Total        int    `json:"total"`
+	Success      int    `json:"success"`
+	Failed       int    `json:"failed"`
+	Skipped      int    `json:"skipped"`
+	// ManualReview counts rows a rule left for a person to decide.
+	ManualReview int `json:"manual_review"`
+	// NotAttempted counts fetched rows left untouched because the run was
+	// cancelled or stopped early.
+	NotAttempted int `json:"not_attempted"`
+	// Unknown counts rows whose submit failed or was cancelled after it may
+	// have reached OMS: the reason may or may not be recorded.
+	Unknown   int  `json:"unknown,omitempty"`
+	Cancelled bool `json:"cancelled"`
+	// Verified, Unverified and StillPending split Success when the run
+	// verified its submits.
+	Verified     int `json:"verified,omitempty"`
+	Unverified   int `json:"unverified,omitempty"`
+	StillPending int `json:"still_pending,omitempty"`
+	// FailureCauses counts failed rows by ProcessedRow.Cause.
+	FailureCauses map[string]int `json:"failure_causes,omitempty"`
+	// FetchError is set when paging through pending outages failed part-way;
+	// the rows fetched before the failure were still processed.
+	FetchError string         `json:"fetch_error,omitempty"`
 	Rows       []ProcessedRow `json:"rows"`
-	StartedAt  time.Time      `json:"started_at"`
-	DurationMs int64          `json:"duration_ms"`
+	// Outages are the pending outages the run fetched, as OMS sent them.
+	// They go to the run history, not to /run callers.
+	Outages    []models.Outage `json:"-"`
+	StartedAt  time.Time       `json:"started_at"`
+	DurationMs int64           `json:"duration_ms"`
 }
 
-// RunAutomation executes the full pipeline once. All progress lines are
-// written to `out`; the structured outcome is returned in RunResult.
-func RunAutomation(limit int, out io.Writer) (*RunResult, error) {
+// RunOptions selects which outages a run processes.
+type RunOptions struct {
+	Limit  int                  // max outages to process; 0 = all
+	Filter models.PendingFilter // server-side filter on the pending list
+	Verify string               // VerifyOff, VerifyPending or VerifyDetail
+	Replay bool                 // api replays a cassette; the run is kept out of the history
+}
+
+// RunAutomation executes the full pipeline once against api. Outages are
+// classified and submitted as they stream in, so the first submit doesn't
+// wait for the whole backlog to be fetched. All progress lines are written
+// to `out`; the structured outcome is returned in RunResult.
+//
+// Cancelling ctx aborts any in-flight OMS call and stops the run before the
+// next outage; outages already fetched but never attempted are recorded as
+// "not_attempted", and a submit that may or may not have reached OMS as
+// "unknown".
+func RunAutomation(ctx context.Context, api oms.API, opts RunOptions, out io.Writer) (*RunResult, error) {
 	lg := log.New(out, "", log.LstdFlags)
 
 	startedAt := time.Now()
-	result := &RunResult{StartedAt: startedAt}
+	profile := config.ActiveProfile()
+	result := &RunResult{StartedAt: startedAt, Profile: profile.Name, Replay: opts.Replay}
+
+	// One rule set per run, even if the rules file is reloaded meanwhile.
+	status := config.CurrentRulesStatus()
+	rules := status.Rules
+	result.RulesSource, result.RulesWarning = status.Source, status.Warning
 
 	lg.Println("═══ OMS Outage Reason Automation ═══")
-	if limit > 0 {
-		lg.Printf("⚙ Limit: Processing max %d outages", limit)
+	lg.Printf("⚙ Profile: %s (%s)", profile.Name, profile.BaseURL)
+	lg.Printf("⚙ Rules: %d from %s", len(rules), status.Source)
+	if status.Warning != "" {
+		lg.Printf("  [WARN] Rules: %s", status.Warning)
+	}
+	if opts.Limit > 0 {
+		lg.Printf("⚙ Limit: Processing max %d outages", opts.Limit)
+	}
+	if f := oms.DescribeFilter(opts.Filter); f != "" {
+		lg.Printf("⚙ Filter: %s", f)
+	}
+	if opts.Verify != VerifyOff {
+		lg.Printf("⚙ Verify: %s", opts.Verify)
 	}
-
-	client := oms.NewClient()
 
 	lg.Println("[Step 0] Logging in...")
-	if err := client.Login(); err != nil {
+	if err := api.Login(ctx); err != nil {
 		return result, fmt.Errorf("login failed: %w", err)
 	}
 
-	lg.Println("[Step 1] Fetching pending outages...")
-	outages, err := client.FetchPendingOutages(limit)
-	if err != nil {
-		return result, fmt.Errorf("fetch pending: %w", err)
-	}
-	lg.Printf("  → Fetched %d outages", len(outages))
+	lg.Println("[Step 1-3] Streaming pending outages and submitting reasons...")
 
-	type processedOutage struct {
-		Outage        models.Outage
-		DurationHours float64
-		Rule          models.DurationRule
-	}
+	// streamCtx stops page fetches once the run is over; outages from the
+	// page already in hand are still yielded and recorded as not attempted.
+	streamCtx, stopStream := context.WithCancel(ctx)
+	defer stopStream()
+
+	var (
+		authErr  error
+		fetchErr error
+		stopNote string
+		seen     int
+	)
+	for o, err := range oms.Take(api.PendingOutages(streamCtx, opts.Filter), opts.Limit) {
+		if err != nil {
+			if stopNote == "" {
+				fetchErr = err
+			}
+			break
+		}
+		seen++
+		result.Outages = append(result.Outages, o)
 
-	var processed []processedOutage
-	for _, o := range outages {
 		hours, err := utils.CalculateDurationFromTimestamps(
 			o.OutageOccurDate, o.OutageOccurTime,
 			o.OutageRestoreDate, o.OutageRestoreTime,
@@ -139,116 +174,400 @@ func RunAutomation(limit int, out io.Writer) (*RunResult, error) {
 			continue
 		}
 
-		rule := utils.ClassifyRule(hours, config.DurationRules)
-
-		// Special rule: KUMBHIYA feeder with duration > 6 hours → reason 25
-		if o.FeederName == "KUMBHIYA" && hours > 6 {
-			rule = models.DurationRule{Label: "KUMBHIYA >6h", MaxHours: 0, ReasonID: 25}
-		}
-
-		processed = append(processed, processedOutage{
-			Outage:        o,
-			DurationHours: hours,
-			Rule:          rule,
-		})
-	}
+		rule := utils.ClassifyRule(o, hours, rules)
 
-	fmt.Fprintln(out)
-	fmt.Fprintln(out, "┌────────────────┬────────┬────────────────┬──────────────────┬──────────┐")
-	fmt.Fprintln(out, "│ Outage ID      │ Hours  │ Bucket         │ Feeder           │ ReasonID │")
-	fmt.Fprintln(out, "├────────────────┼────────┼────────────────┼──────────────────┼──────────┤")
-	for _, p := range processed {
-		fmt.Fprintf(out, "│ %-14s │ %5.2f  │ %-14s │ %-16s │ %-8d │\n",
-			p.Outage.ID, p.DurationHours,
-			p.Rule.Label, p.Outage.FeederName, p.Rule.ReasonID)
-	}
-	fmt.Fprintln(out, "└────────────────┴────────┴────────────────┴──────────────────┴──────────┘")
-
-	toProcess := processed
-	if limit > 0 && len(processed) > limit {
-		toProcess = processed[:limit]
-		lg.Printf("⚙ Limiting to first %d outages (out of %d total)", limit, len(processed))
-	}
-
-	result.Total = len(toProcess)
-	lg.Printf("[Step 2 & 3] Processing %d outages...", len(toProcess))
-
-	for i, p := range toProcess {
-		id := p.Outage.ID
+		result.Total++
 		row := ProcessedRow{
-			OutageID: id,
-			Hours:    p.DurationHours,
-			Bucket:   p.Rule.Label,
-			Feeder:   p.Outage.FeederName,
-			ReasonID: p.Rule.ReasonID,
+			OutageID: o.ID,
+			Hours:    hours,
+			Bucket:   rule.Label,
+			Feeder:   o.FeederName,
+			ReasonID: rule.ReasonID,
+			Reason:   config.ReasonName(rule.ReasonID),
 		}
 
-		isOverride := p.Rule.MaxHours == 0 && p.Rule.ReasonID != 0
-		is1573 := p.DurationHours >= 15.72 && p.DurationHours <= 15.74
-		if p.DurationHours > 8 && !is1573 && !isOverride {
-			lg.Printf("  [%d/%d] Outage %s | %.2fh | ⊘ SKIPPED (no matching rule)",
-				i+1, len(toProcess), id, p.DurationHours)
-			row.Status = "skipped"
-			row.Note = "no matching rule"
+		if stopNote == "" && ctx.Err() != nil {
+			lg.Printf("⚠ Run cancelled: remaining outages not attempted")
+			stopNote = "run cancelled"
+			result.Cancelled = true
+			stopStream()
+		}
+		if stopNote != "" {
+			row.Status = "not_attempted"
+			row.Note = stopNote
 			result.Rows = append(result.Rows, row)
-			result.Skipped++
+			result.NotAttempted++
 			continue
 		}
 
-		lg.Printf("  [%d/%d] Outage %s | %.2fh | reason_id=%d",
-			i+1, len(toProcess), id, p.DurationHours, p.Rule.ReasonID)
-
-		locIDs, err := client.FetchLocIDs(id, p.Outage.FeederID)
-		if err != nil {
-			lg.Printf("    ✗ loc_ids fetch failed: %v", err)
-			row.Status = "failed"
-			row.Note = "loc_ids fetch: " + err.Error()
-			result.Rows = append(result.Rows, row)
+		row, err = processOutage(ctx, api, lg, seen, o, rule, row, opts.Verify)
+		result.Rows = append(result.Rows, row)
+		switch row.Status {
+		case "submitted":
+			result.Success++
+			switch row.Verification {
+			case Verified:
+				result.Verified++
+			case Unverified:
+				result.Unverified++
+			case StillPending:
+				result.StillPending++
+			}
+		case "skipped":
+			result.Skipped++
+		case "manual_review":
+			result.ManualReview++
+		case "not_attempted":
+			result.NotAttempted++
+			result.Cancelled = true
+		case "unknown":
+			result.Unknown++
+			result.Cancelled = result.Cancelled || ctx.Err() != nil
+		default:
 			result.Failed++
-			continue
+			if result.FailureCauses == nil {
+				result.FailureCauses = map[string]int{}
+			}
+			result.FailureCauses[row.Cause]++
 		}
-		if len(locIDs) == 0 {
-			lg.Printf("    ✗ No loc_ids in GeoJSON")
-			row.Status = "failed"
-			row.Note = "no loc_ids in GeoJSON"
-			result.Rows = append(result.Rows, row)
-			result.Failed++
-			continue
+		if err != nil {
+			authErr = err
+			stopNote = "stopped: OMS authentication failed"
+			stopStream()
 		}
+	}
 
-		pickedLocID := locIDs[rand.Intn(len(locIDs))]
-		lg.Printf("    → loc_id=%d (picked from %d poles)", pickedLocID, len(locIDs))
-
-		if err := client.SubmitReason(id, pickedLocID, p.Rule.ReasonID); err != nil {
-			lg.Printf("    ✗ Submit failed: %v", err)
-			row.Status = "failed"
-			row.Note = "submit: " + err.Error()
-			result.Rows = append(result.Rows, row)
-			result.Failed++
-			continue
+	if fetchErr != nil {
+		if errors.Is(fetchErr, context.Canceled) && ctx.Err() != nil {
+			result.Cancelled = true
+		} else if seen == 0 {
+			return result, fmt.Errorf("fetch pending: %w", fetchErr)
+		} else {
+			// Keep what we already processed rather than failing the run.
+			lg.Printf("  [WARN] Pending fetch stopped early after %d outages: %v", seen, fetchErr)
+			result.FetchError = fetchErr.Error()
 		}
-
-		lg.Printf("    ✓ Submitted")
-		row.Status = "submitted"
-		result.Rows = append(result.Rows, row)
-		result.Success++
-
-		lg.Printf("    → Waiting %dms before next outage...", config.DelayBetweenOutages)
-		time.Sleep(time.Duration(config.DelayBetweenOutages) * time.Millisecond)
 	}
 
+	printResultTable(out, result.Rows)
+
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "─── Results ───")
 	fmt.Fprintf(out, "  Total:   %d\n", result.Total)
 	fmt.Fprintf(out, "  Success: %d\n", result.Success)
 	fmt.Fprintf(out, "  Failed:  %d\n", result.Failed)
 	fmt.Fprintf(out, "  Skipped: %d\n", result.Skipped)
+	if result.ManualReview > 0 {
+		fmt.Fprintf(out, "  Manual review: %d\n", result.ManualReview)
+	}
+	if result.NotAttempted > 0 {
+		fmt.Fprintf(out, "  Not attempted: %d\n", result.NotAttempted)
+	}
+	if result.Unknown > 0 {
+		fmt.Fprintf(out, "  Unknown: %d (OMS may have recorded these — check before resubmitting)\n", result.Unknown)
+	}
+	for _, cause := range slices.Sorted(maps.Keys(result.FailureCauses)) {
+		fmt.Fprintf(out, "    ✗ %-40s %d\n", cause, result.FailureCauses[cause])
+	}
+	if opts.Verify != VerifyOff {
+		fmt.Fprintf(out, "  Verified: %d | Unverified: %d | Still pending: %d\n",
+			result.Verified, result.Unverified, result.StillPending)
+	}
 	lg.Println("═══ Done ═══")
 
 	result.DurationMs = time.Since(startedAt).Milliseconds()
+	// Replayed runs saw recorded outages, not the live queue.
+	if !opts.Replay {
+		if err := appendHistory(config.HistoryPath(), result); err != nil {
+			lg.Printf("  [WARN] Run history: %v", err)
+		}
+	}
+	if authErr != nil {
+		return result, fmt.Errorf("run stopped: %w", authErr)
+	}
+	if result.Cancelled {
+		return result, fmt.Errorf("run cancelled: %w", ctx.Err())
+	}
 	return result, nil
 }
 
+// processOutage fetches poles and submits the classified reason for one
+// outage, filling in the row's status. Failures are recorded in the row; the
+// returned error is only set for those that stop the whole run (see stopsRun).
+func processOutage(ctx context.Context, api oms.API, lg *log.Logger, n int, o models.Outage, rule models.DurationRule, row ProcessedRow, verify string) (ProcessedRow, error) {
+	id := o.ID
+
+	switch utils.RuleAction(rule) {
+	case models.RuleSkip:
+		lg.Printf("  [%d] Outage %s | %.2fh | ⊘ SKIPPED (%s)", n, id, row.Hours, rule.Label)
+		row.Status = "skipped"
+		row.Note = rule.Label
+		return row, nil
+	case models.RuleManualReview:
+		lg.Printf("  [%d] Outage %s | %.2fh | ✋ MANUAL REVIEW (%s)", n, id, row.Hours, rule.Label)
+		row.Status = "manual_review"
+		row.Note = rule.Label
+		return row, nil
+	}
+
+	lg.Printf("  [%d] Outage %s | %.2fh | reason_id=%d (%s)", n, id, row.Hours, row.ReasonID, row.Reason)
+
+	octx, attempts := oms.CountAttempts(ctx)
+
+	detail, err := api.FetchOutageDetail(octx, id, o.FeederID)
+	if err != nil && ctx.Err() != nil {
+		// Nothing has been sent for this outage yet.
+		lg.Printf("    ⊘ Cancelled before submitting")
+		row.Status = "not_attempted"
+		row.Note = "run cancelled"
+		row.Attempts = attempts()
+		return row, nil
+	}
+	if err != nil {
+		lg.Printf("    ✗ loc_ids fetch failed: %v", err)
+		row.Status = "failed"
+		row.Note = "loc_ids fetch: " + err.Error()
+		row.Cause = oms.Cause(err)
+		row.Attempts = attempts()
+		return row, stopsRun(err)
+	}
+	if facts := oms.DescribeFacts(detail.Data); facts != "" {
+		lg.Printf("    · %s", facts)
+	}
+	row.Fault = detail.Data.FaultLocation
+	locIDs := detail.PoleIDs
+	if len(locIDs) == 0 {
+		why := oms.DescribeTopology(detail.Topology)
+		lg.Printf("    ✗ No loc_ids in GeoJSON: %s", why)
+		row.Status = "failed"
+		row.Note = "no loc_ids in GeoJSON: " + why
+		row.Cause = "no HT poles on feeder"
+		row.Attempts = attempts()
+		return row, nil
+	}
+
+	// The poles come straight from the feeder's HT pole list, so there is
+	// nothing for oms.ValidatePayload to catch here; it guards hand-entered
+	// pairs in `submit`.
+	picked := pickPoles(locIDs, rule.PoleCount)
+	items := make([]models.ReasonPayloadItem, len(picked))
+	for i, locID := range picked {
+		items[i] = models.ReasonPayloadItem{LocID: locID, ReasonID: row.ReasonID}
+	}
+	row.LocIDs = picked
+	lg.Printf("    → loc_id=%v (picked from %d poles)", picked, len(locIDs))
+
+	if err := api.SubmitReasons(octx, id, items); err != nil && (ctx.Err() != nil || errors.Is(err, oms.ErrSubmitUnknown)) {
+		// The request may have reached OMS before it failed or was cancelled.
+		lg.Printf("    ? Submit outcome unknown: %v", err)
+		row.Status = "unknown"
+		row.Note = "OMS may have recorded the reason: " + err.Error()
+		if ctx.Err() != nil {
+			row.Note = "cancelled during submit; OMS may have recorded the reason"
+		}
+		row.Attempts = attempts()
+		return row, nil
+	} else if err != nil {
+		lg.Printf("    ✗ Submit failed: %v", err)
+		row.Status = "failed"
+		row.Note = err.Error()
+		row.Cause = oms.Cause(err)
+		row.Attempts = attempts()
+		return row, stopsRun(err)
+	}
+
+	lg.Printf("    ✓ Submitted")
+	row.Status = "submitted"
+
+	if verify != VerifyOff {
+		var note string
+		row.Verification, note = verifySubmit(octx, api, verify, o, items)
+		if row.Verification == Verified {
+			lg.Printf("    ✓ Verified (%s)", verify)
+		} else {
+			lg.Printf("    ⚠ %s: %s", row.Verification, note)
+			row.Note = note
+		}
+	}
+	row.Attempts = attempts()
+	return row, nil
+}
+
+// stopsRun returns err if it should stop the whole run, nil otherwise. A
+// rejected login can't get better for the next outage; anything else only
+// fails the outage at hand.
+func stopsRun(err error) error {
+	if errors.Is(err, oms.ErrAuth) {
+		return err
+	}
+	return nil
+}
+
+// pickPoles returns n distinct poles chosen at random (n <= 0 means 1). If
+// the feeder has fewer poles, all of them are returned.
+func pickPoles(poles []int, n int) []int {
+	if n <= 0 {
+		n = 1
+	}
+	if n > len(poles) {
+		n = len(poles)
+	}
+	picked := make([]int, 0, n)
+	for _, i := range rand.Perm(len(poles))[:n] {
+		picked = append(picked, poles[i])
+	}
+	return picked
+}
+
+// printResultTable writes the per-outage outcome table shown at the end of
+// a CLI run.
+func printResultTable(out io.Writer, rows []ProcessedRow) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "┌────────────────┬────────┬────────────────┬──────────────────┬─────┬──────────────────────────┬───────────────┐")
+	fmt.Fprintln(out, "│ Outage ID      │ Hours  │ Bucket         │ Feeder           │ ID  │ Reason                   │ Status        │")
+	fmt.Fprintln(out, "├────────────────┼────────┼────────────────┼──────────────────┼─────┼──────────────────────────┼───────────────┤")
+	for _, r := range rows {
+		id := ""
+		if r.ReasonID != 0 {
+			id = strconv.Itoa(r.ReasonID)
+		}
+		fmt.Fprintf(out, "│ %-14s │ %5.2f  │ %-14s │ %-16s │ %-3s │ %-24s │ %-13s │\n",
+			r.OutageID, r.Hours, truncate(r.Bucket, 14), r.Feeder, id, truncate(r.Reason, 24), r.Status)
+	}
+	fmt.Fprintln(out, "└────────────────┴────────┴────────────────┴──────────────────┴─────┴──────────────────────────┴───────────────┘")
+}
+
+// truncate shortens s to at most n runes, marking the cut with "…".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// cassetteMode and cassettePath select recording or offline replay of OMS
+// traffic. They come from OMS_CASSETTE_MODE / OMS_CASSETTE and can be
+// overridden with -record / -replay.
+var (
+	cassetteMode = os.Getenv("OMS_CASSETTE_MODE")
+	cassettePath = os.Getenv("OMS_CASSETTE")
+)
+
+// traceMode and tracePath switch on the OMS traffic trace (OMS_TRACE /
+// OMS_TRACE_FILE, or -trace / -trace-file). The trace goes to its own file so
+// the run log stays readable; it is opened on first use and appended to.
+var (
+	traceMode = os.Getenv("OMS_TRACE")
+	tracePath = envOr("OMS_TRACE_FILE", "oms-trace.log")
+
+	traceOnce sync.Once
+	traceOut  io.Writer
+	traceErr  error
+)
+
+// traceWriter opens the trace file once per process.
+func traceWriter() (io.Writer, error) {
+	traceOnce.Do(func() {
+		f, err := os.OpenFile(tracePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			traceErr = fmt.Errorf("open trace file: %w", err)
+			return
+		}
+		traceOut = f
+		log.Printf("⚙ OMS trace (%s) → %s", traceMode, tracePath)
+	})
+	return traceOut, traceErr
+}
+
+// rulesPath is the duration rules file (OMS_RULES_FILE or -rules); empty
+// means the built-in config.DurationRules. See watchRules for reloading.
+var rulesPath = os.Getenv("OMS_RULES_FILE")
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// newOMSClient builds the OMS client used by both CLI and server runs.
+func newOMSClient() (*oms.Client, error) {
+	client := oms.NewClient()
+	if cassetteMode != "" {
+		if cassettePath == "" {
+			return nil, fmt.Errorf("cassette mode %q needs a file (OMS_CASSETTE, -record or -replay)", cassetteMode)
+		}
+		if err := client.UseCassette(cassetteMode, cassettePath); err != nil {
+			return nil, err
+		}
+		log.Printf("⚙ OMS traffic: %s %s", cassetteMode, cassettePath)
+	}
+
+	mode, err := oms.ParseTraceMode(traceMode)
+	if err != nil {
+		return nil, err
+	}
+	if mode != oms.TraceOff {
+		out, err := traceWriter()
+		if err != nil {
+			return nil, err
+		}
+		client.UseTrace(mode, out)
+	}
+	return client, nil
+}
+
+// loadConfig selects the OMS profile, sets up the HTTP transport, loads the
+// reason catalog override and the rules file, and checks the duration rules
+// against the catalog.
+func loadConfig(profile string) error {
+	if _, err := config.SelectProfile(profile); err != nil {
+		return err
+	}
+	t, err := config.TransportFromEnv()
+	if err != nil {
+		return err
+	}
+	if _, err := oms.UseTransport(t); err != nil {
+		return fmt.Errorf("OMS transport: %w", err)
+	}
+	if path := os.Getenv("OMS_REASONS_FILE"); path != "" {
+		if err := config.LoadReasons(path); err != nil {
+			return err
+		}
+	}
+	if rulesPath != "" {
+		if err := config.LoadRules(rulesPath); err != nil {
+			return err
+		}
+		log.Printf("⚙ Duration rules: %s (%d rules)", rulesPath, len(config.Rules()))
+	}
+	if err := config.ValidateRules(config.Rules()); err != nil {
+		return err
+	}
+	logLintWarnings(config.Rules())
+	return nil
+}
+
+// loadCredentials loads the OMS credentials from the configured provider.
+// Replayed runs never reach OMS, so they may go without.
+func loadCredentials() error {
+	p, err := config.CredentialProviderFromEnv()
+	if err == nil {
+		err = config.LoadCredentials(p)
+	}
+	if err != nil {
+		if cassetteMode == oms.CassetteReplay {
+			log.Printf("⚙ No OMS credentials (%v) — fine for replay", err)
+			return nil
+		}
+		return fmt.Errorf("OMS credentials: %w", err)
+	}
+	log.Printf("⚙ OMS credentials: %s (empNo=%s)", config.CredentialSource(), config.Creds().EmpNo)
+	return nil
+}
+
 func main() {
 	// Force IST for all time operations regardless of host TZ.
 	ist, err := time.LoadLocation("Asia/Kolkata")
@@ -258,10 +577,69 @@ func main() {
 	time.Local = ist
 	rand.Seed(time.Now().UnixNano())
 
+	if len(os.Args) > 1 {
+		var cmd func([]string) error
+		switch os.Args[1] {
+		case "submit":
+			cmd = runSubmitCommand
+		case "creds":
+			cmd = runCredsCommand
+		case "diag":
+			cmd = runDiagCommand
+		case "rules":
+			cmd = runRulesCommand
+		}
+		if cmd != nil {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("FATAL: %v", err)
+			}
+			return
+		}
+	}
+
 	serverFlag := flag.Bool("server", false, "Run as HTTP server instead of one-shot CLI")
 	limitFlag := flag.Int("limit", 0, "Limit number of outages to process (0 = process all)")
+	recordFlag := flag.String("record", "", "Record OMS traffic (redacted) into this cassette file")
+	replayFlag := flag.String("replay", "", "Replay OMS traffic from this cassette file instead of calling OMS")
+	verifyFlag := flag.String("verify", os.Getenv("OMS_VERIFY"), "Re-check submits: pending (outage left the pending list) or detail (reason recorded)")
+	flag.StringVar(&traceMode, "trace", traceMode, "Trace OMS traffic to the trace file: on, or bodies to include redacted bodies")
+	flag.StringVar(&tracePath, "trace-file", tracePath, "File the OMS trace is appended to")
+	profileFlag := flag.String("profile", config.ProfileName(), "OMS endpoint profile: production, training or local")
+	flag.StringVar(&rulesPath, "rules", rulesPath, "JSON file with the duration rules (default: built-in rules)")
+
+	var filter models.PendingFilter
+	flag.StringVar(&filter.Feeder, "feeder", "", "Only outages on this feeder name")
+	flag.StringVar(&filter.Substation, "substation", "", "Only outages under this substation")
+	flag.StringVar(&filter.Circle, "circle", "", "Only outages in this circle")
+	flag.StringVar(&filter.Division, "division", "", "Only outages in this division")
+	flag.StringVar(&filter.Subdivision, "subdivision", "", "Only outages in this subdivision")
+	flag.StringVar(&filter.OutageType, "outage-type", "", "Only outages of this type (ID or name)")
+	flag.StringVar(&filter.From, "from", "", "Only outages that occurred on or after this date (YYYY-MM-DD)")
+	flag.StringVar(&filter.To, "to", "", "Only outages that occurred on or before this date (YYYY-MM-DD)")
 	flag.Parse()
 
+	if err := loadConfig(*profileFlag); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	switch {
+	case *recordFlag != "" && *replayFlag != "":
+		log.Fatal("-record and -replay are mutually exclusive")
+	case *recordFlag != "":
+		cassetteMode, cassettePath = oms.CassetteRecord, *recordFlag
+	case *replayFlag != "":
+		cassetteMode, cassettePath = oms.CassetteReplay, *replayFlag
+	}
+
+	// No credentials means no run: there are no built-in defaults to fall
+	// back to, in server mode or otherwise.
+	if err := loadCredentials(); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	if _, err := oms.ParseTraceMode(traceMode); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
 	if *serverFlag || os.Getenv("RUN_MODE") == "server" {
 		runServer()
 		return
@@ -274,7 +652,26 @@ func main() {
 		}
 	}
 
-	if _, err := RunAutomation(*limitFlag, os.Stdout); err != nil {
+	// Ctrl-C / SIGTERM stops the run cleanly between outages.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := newOMSClient()
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	verify, err := ParseVerifyMode(*verifyFlag)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	opts := RunOptions{Limit: *limitFlag, Filter: filter, Verify: verify, Replay: cassetteMode == oms.CassetteReplay}
+	if _, err := RunAutomation(ctx, client, opts, os.Stdout); err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Printf("Stopped: %v", err)
+			return
+		}
 		log.Fatalf("FATAL: %v", err)
 	}
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+
+	"oms-automtion/models"
+	"oms-automtion/oms"
+)
+
+// outage returns a pending outage on feederID that began at 10:00 on
+// 2026-01-28 and was restored at the given time the same day.
+func outage(id string, feederID int, restored string) models.Outage {
+	return models.Outage{
+		ID: id, FeederID: feederID, FeederName: fmt.Sprintf("F%d", feederID),
+		OutageOccurDate: "2026-01-28", OutageOccurTime: "10:00:00.000",
+		OutageRestoreDate: "2026-01-28", OutageRestoreTime: restored,
+	}
+}
+
+func TestRunAutomationCounts(t *testing.T) {
+	t.Setenv("OMS_HISTORY_FILE", "off")
+
+	api := oms.NewMemory().
+		AddOutage(outage("ok", 1, "10:06:00.000"), 11, 12). // ≤ 15 min → submit
+		AddOutage(outage("long", 1, "19:00:00.000")).       // > 8 hours → skip
+		AddOutage(models.Outage{ID: "no-time", FeederID: 1}).
+		AddOutage(outage("no-poles", 2, "10:30:00.000")).
+		AddOutage(outage("detail-500", 1, "12:00:00.000")).
+		AddOutage(outage("dropped", 1, "10:12:00.000")).
+		AddOutage(outage("auth", 1, "10:12:00.000")).
+		AddOutage(outage("after", 1, "10:12:00.000")).
+		FailOn(oms.OpFetchDetail, "detail-500", 1, &oms.APIError{Op: "detail", Status: 500}).
+		FailOn(oms.OpSubmitReason, "dropped", 1, fmt.Errorf("%w: connection reset", oms.ErrSubmitUnknown)).
+		FailOn(oms.OpSubmitReason, "auth", 1, fmt.Errorf("%w: token revoked", oms.ErrAuth))
+
+	result, err := RunAutomation(context.Background(), api, RunOptions{}, io.Discard)
+	if err == nil {
+		t.Error("RunAutomation succeeded, want the auth failure to stop the run")
+	}
+
+	got := [...]int{result.Total, result.Success, result.Skipped, result.Failed, result.Unknown, result.NotAttempted}
+	if want := [...]int{7, 1, 1, 3, 1, 1}; got != want {
+		t.Errorf("total, submitted, skipped, failed, unknown, not attempted = %v, want %v", got, want)
+	}
+	wantCauses := []string{"OMS authentication failed", "OMS unavailable (HTTP 500)", "no HT poles on feeder"}
+	if causes := slices.Sorted(maps.Keys(result.FailureCauses)); !slices.Equal(causes, wantCauses) {
+		t.Errorf("failure causes = %q, want %q", causes, wantCauses)
+	}
+
+	status := map[string]string{}
+	for _, r := range result.Rows {
+		status[r.OutageID] = r.Status
+	}
+	wantStatus := map[string]string{
+		"ok": "submitted", "long": "skipped", "no-time": "parse_error", "no-poles": "failed",
+		"detail-500": "failed", "dropped": "unknown", "auth": "failed", "after": "not_attempted",
+	}
+	if !maps.Equal(status, wantStatus) {
+		t.Errorf("row statuses = %v, want %v", status, wantStatus)
+	}
+
+	items := api.Submitted("ok")
+	if len(items) != 1 || items[0].ReasonID != 21 || (items[0].LocID != 11 && items[0].LocID != 12) {
+		t.Errorf("submitted for ok = %+v, want one pair with reason 21 on pole 11 or 12", items)
+	}
+}
+
+// cancelOn cancels the run when op is called for outage id, as if the user
+// stopped it while that call was in flight.
+type cancelOn struct {
+	*oms.Memory
+	op     oms.Op
+	id     string
+	cancel context.CancelFunc
+}
+
+func (c cancelOn) FetchOutageDetail(ctx context.Context, outageID string, feederID int) (*models.OutageDetail, error) {
+	if c.op == oms.OpFetchDetail && outageID == c.id {
+		c.cancel()
+	}
+	return c.Memory.FetchOutageDetail(ctx, outageID, feederID)
+}
+
+func (c cancelOn) SubmitReasons(ctx context.Context, outageID string, items []models.ReasonPayloadItem) error {
+	if c.op == oms.OpSubmitReason && outageID == c.id {
+		c.cancel()
+	}
+	return c.Memory.SubmitReasons(ctx, outageID, items)
+}
+
+func TestRunAutomationCancelled(t *testing.T) {
+	t.Setenv("OMS_HISTORY_FILE", "off")
+
+	tests := []struct {
+		name   string
+		op     oms.Op
+		status string // of outage "b"
+	}{
+		{"during detail fetch", oms.OpFetchDetail, "not_attempted"},
+		{"during submit", oms.OpSubmitReason, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			mem := oms.NewMemory()
+			for _, id := range []string{"a", "b", "c"} {
+				mem.AddOutage(outage(id, 1, "10:06:00.000"), 11)
+			}
+
+			result, err := RunAutomation(ctx, cancelOn{mem, tt.op, "b", cancel}, RunOptions{}, io.Discard)
+			if err == nil || !result.Cancelled {
+				t.Errorf("RunAutomation = %v, cancelled %v; want a cancelled run", err, result.Cancelled)
+			}
+			var got []string
+			for _, r := range result.Rows {
+				got = append(got, r.Status)
+			}
+			if want := []string{"submitted", tt.status, "not_attempted"}; !slices.Equal(got, want) {
+				t.Errorf("row statuses = %q, want %q", got, want)
+			}
+			if result.Failed != 0 {
+				t.Errorf("%d rows failed, want 0", result.Failed)
+			}
+		})
+	}
+}
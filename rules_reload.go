@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"oms-automtion/config"
+)
+
+// watchRules reloads the rules file on SIGHUP and whenever its modification
+// time or size changes, until ctx is done. A rejected file leaves the last
+// good rules active; the error shows up in config.CurrentRulesStatus and
+// so in the UI.
+func watchRules(ctx context.Context, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(time.Duration(config.RulesPollInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	last := statRules(path)
+	log.Printf("⚙ Watching %s for rule changes (SIGHUP reloads too)", path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("⚙ SIGHUP: reloading rules from %s", path)
+		case <-ticker.C:
+			if statRules(path) == last {
+				continue
+			}
+		}
+		last = statRules(path)
+		reloadRules(path)
+	}
+}
+
+type rulesFileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statRules(path string) rulesFileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return rulesFileStamp{}
+	}
+	return rulesFileStamp{fi.ModTime(), fi.Size()}
+}
+
+func reloadRules(path string) {
+	if err := config.LoadRules(path); err != nil {
+		log.Printf("  [WARN] Rules file rejected, keeping the previous rules: %v", err)
+		return
+	}
+	log.Printf("  ✓ Rules reloaded from %s (%d rules)", path, len(config.Rules()))
+	logLintWarnings(config.Rules())
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/subtle"
 	_ "embed"
 	"encoding/json"
@@ -12,13 +13,17 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+	"oms-automtion/oms"
 )
 
 //go:embed index.html
 var indexHTML []byte
 
-// runMu serializes /run requests — the OMS API and rate-limit logic assume
-// one job at a time.
+// runMu serializes /run requests and password rotation — the OMS API and
+// rate-limit logic assume one job at a time.
 var runMu sync.Mutex
 
 // passcodeGuard tracks failed passcode attempts and triggers a lockout
@@ -76,13 +81,37 @@ func runServer() {
 	}
 	guard := &passcodeGuard{expected: passcode}
 
+	// Belt and braces: main already refuses to start without credentials.
+	if config.Creds().Password == "" && cassetteMode != oms.CassetteReplay {
+		log.Fatal("OMS credentials are required in server mode; see OMS_CREDENTIALS")
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", handleIndex)
 	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/profile", handleProfile)
+	mux.HandleFunc("GET /reasons", handleReasons)
+	mux.HandleFunc("GET /rules", handleRules)
+	mux.HandleFunc("PUT /rules", makeSaveRulesHandler(guard))
+	mux.HandleFunc("POST /rules/lint", handleLintRules)
+	mux.HandleFunc("POST /rules/simulate", makeSimulateHandler(guard))
 	mux.HandleFunc("/run", makeRunHandler(guard))
 
+	// Password rotation is only exposed when an admin token is configured.
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		if len(token) < minAdminTokenLen {
+			log.Fatalf("ADMIN_TOKEN must be at least %d characters", minAdminTokenLen)
+		}
+		mux.HandleFunc("POST /admin/password", makePasswordHandler(&passcodeGuard{expected: token}))
+	}
+
+	if rulesPath != "" {
+		go watchRules(context.Background(), rulesPath)
+	}
+
 	addr := ":" + port
-	log.Printf("OMS automation server listening on %s", addr)
+	profile := config.ActiveProfile()
+	log.Printf("OMS automation server listening on %s (profile %s → %s)", addr, profile.Name, profile.BaseURL)
 
 	srv := &http.Server{
 		Addr:              addr,
@@ -123,6 +152,140 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"ok":true}`))
 }
 
+// handleProfile reports which OMS environment runs will talk to.
+func handleProfile(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.ActiveProfile())
+}
+
+// handleReasons serves the OMS reason catalog.
+func handleReasons(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.Reasons())
+}
+
+// handleRules serves the active duration rules, their source and the
+// warning left by a rejected reload, if any.
+func handleRules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.CurrentRulesStatus())
+}
+
+type rulesLintResponse struct {
+	OK       bool                 `json:"ok"`
+	Error    string               `json:"error,omitempty"`
+	Findings []config.LintFinding `json:"findings"`
+}
+
+// decodeRulesBody reads a rules file body ({"rules": [...]}) and lints it.
+func decodeRulesBody(w http.ResponseWriter, r *http.Request) ([]models.DurationRule, *rulesLintResponse) {
+	var body config.RulesFile
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		return nil, &rulesLintResponse{Error: "bad JSON body: " + err.Error(), Findings: []config.LintFinding{}}
+	}
+	resp := &rulesLintResponse{Findings: config.LintRules(body.Rules)}
+	if err := config.ValidateRules(body.Rules); err != nil {
+		resp.Error = err.Error()
+		return body.Rules, resp
+	}
+	resp.OK = true
+	return body.Rules, resp
+}
+
+// handleLintRules serves POST /rules/lint: it lints the rules in the body
+// ({"rules": [...]}) without saving them.
+func handleLintRules(w http.ResponseWriter, r *http.Request) {
+	_, resp := decodeRulesBody(w, r)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// makeSaveRulesHandler serves PUT /rules: rules that pass the linter
+// replace the rules file and take effect at once. Rules with lint errors are
+// refused with the findings; warnings don't block the save.
+func makeSaveRulesHandler(guard *passcodeGuard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := guard.check(r.Header.Get("X-Passcode")); err != nil {
+			writeJSON(w, http.StatusUnauthorized, rulesLintResponse{Error: err.Error()})
+			return
+		}
+		if rulesPath == "" {
+			writeJSON(w, http.StatusConflict, rulesLintResponse{Error: "no rules file to save to; start the server with OMS_RULES_FILE or -rules"})
+			return
+		}
+		rules, resp := decodeRulesBody(w, r)
+		if !resp.OK {
+			writeJSON(w, http.StatusUnprocessableEntity, resp)
+			return
+		}
+		if err := config.SaveRules(rulesPath, rules); err != nil {
+			resp.OK, resp.Error = false, err.Error()
+			writeJSON(w, http.StatusInternalServerError, resp)
+			return
+		}
+		log.Printf("⚙ Rules saved from the UI to %s (%d rules)", rulesPath, len(rules))
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+type simulateResponse struct {
+	OK     bool              `json:"ok"`
+	Error  string            `json:"error,omitempty"`
+	Report *SimulationReport `json:"report,omitempty"`
+}
+
+// makeSimulateHandler serves POST /rules/simulate. The body holds the
+// candidate rules and, optionally, the outages to replay; without outages
+// the run history is replayed, limited to runs between "since" and "until"
+// (YYYY-MM-DD, both inclusive) if given:
+//
+//	{"rules": [...], "outages": [...], "since": "2026-01-01", "until": "2026-01-31"}
+func makeSimulateHandler(guard *passcodeGuard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := guard.check(r.Header.Get("X-Passcode")); err != nil {
+			writeJSON(w, http.StatusUnauthorized, simulateResponse{Error: err.Error()})
+			return
+		}
+
+		var body struct {
+			Rules   []models.DurationRule `json:"rules"`
+			Outages []models.Outage       `json:"outages"`
+			Since   string                `json:"since"`
+			Until   string                `json:"until"`
+		}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 32<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, simulateResponse{Error: "bad JSON body: " + err.Error()})
+			return
+		}
+		if err := config.ValidateRules(body.Rules); err != nil {
+			writeJSON(w, http.StatusBadRequest, simulateResponse{Error: err.Error()})
+			return
+		}
+
+		source := "request"
+		outages := body.Outages
+		if outages == nil {
+			since, until, err := historyRange(body.Since, body.Until)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, simulateResponse{Error: err.Error()})
+				return
+			}
+			entries, err := readHistory(config.HistoryPath(), config.ActiveProfile().Name, since, until)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, simulateResponse{Error: err.Error()})
+				return
+			}
+			outages = historyOutages(entries)
+			source = fmt.Sprintf("history (%d runs)", len(entries))
+		}
+
+		status := config.CurrentRulesStatus()
+		rep := Simulate(outages, status.Rules, body.Rules)
+		rep.Source, rep.ActiveSource, rep.CandidateSource = source, status.Source, "request"
+		writeJSON(w, http.StatusOK, simulateResponse{OK: true, Report: rep})
+	}
+}
+
 func makeRunHandler(guard *passcodeGuard) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -139,12 +302,35 @@ func makeRunHandler(guard *passcodeGuard) http.HandlerFunc {
 			return
 		}
 
-		limit := 0
-		if v := r.URL.Query().Get("limit"); v != "" {
+		q := r.URL.Query()
+		opts := RunOptions{Filter: models.PendingFilter{
+			Feeder:      q.Get("feeder"),
+			Substation:  q.Get("substation"),
+			Circle:      q.Get("circle"),
+			Division:    q.Get("division"),
+			Subdivision: q.Get("subdivision"),
+			OutageType:  q.Get("outage_type"),
+			From:        q.Get("from"),
+			To:          q.Get("to"),
+		}, Replay: cassetteMode == oms.CassetteReplay}
+		if v := q.Get("limit"); v != "" {
 			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-				limit = n
+				opts.Limit = n
 			}
 		}
+		if _, err := oms.FilterData(opts.Filter); err != nil {
+			writeJSON(w, http.StatusBadRequest, runResponse{OK: false, Error: err.Error()})
+			return
+		}
+		verify := os.Getenv("OMS_VERIFY")
+		if q.Has("verify") {
+			verify = q.Get("verify")
+		}
+		var err error
+		if opts.Verify, err = ParseVerifyMode(verify); err != nil {
+			writeJSON(w, http.StatusBadRequest, runResponse{OK: false, Error: err.Error()})
+			return
+		}
 
 		if !runMu.TryLock() {
 			writeJSON(w, http.StatusConflict, runResponse{
@@ -155,8 +341,15 @@ func makeRunHandler(guard *passcodeGuard) http.HandlerFunc {
 		}
 		defer runMu.Unlock()
 
+		client, err := newOMSClient()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, runResponse{OK: false, Error: err.Error()})
+			return
+		}
+
 		var buf bytes.Buffer
-		result, err := RunAutomation(limit, &buf)
+		// r.Context() is cancelled when the caller disconnects, which stops the run.
+		result, err := RunAutomation(r.Context(), client, opts, &buf)
 
 		resp := runResponse{
 			OK:     err == nil,
@@ -170,6 +363,64 @@ func makeRunHandler(guard *passcodeGuard) http.HandlerFunc {
 	}
 }
 
+const minAdminTokenLen = 16
+
+type passwordResponse struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	Persisted bool   `json:"persisted"` // false: lasts until restart
+}
+
+// makePasswordHandler serves POST /admin/password {"password": "..."}. The
+// new password is tried with a real OMS login first and only then replaces
+// the current one, so a typo can't lock the automation out.
+func makePasswordHandler(guard *passcodeGuard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := guard.check(r.Header.Get("X-Admin-Token")); err != nil {
+			writeJSON(w, http.StatusUnauthorized, passwordResponse{Error: err.Error()})
+			return
+		}
+
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil || body.Password == "" {
+			writeJSON(w, http.StatusBadRequest, passwordResponse{Error: "want JSON body {\"password\": \"...\"}"})
+			return
+		}
+
+		// The test login counts against the same OMS budget as a run, and a
+		// run must not re-login half-way through with a password being
+		// replaced under it.
+		if !runMu.TryLock() {
+			writeJSON(w, http.StatusConflict, passwordResponse{Error: "a run is in progress; try again when it finishes"})
+			return
+		}
+		defer runMu.Unlock()
+
+		client, err := newOMSClient()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, passwordResponse{Error: err.Error()})
+			return
+		}
+		next := config.Creds()
+		next.Password = body.Password
+		client.Creds = &next
+		if err := client.Login(r.Context()); err != nil {
+			writeJSON(w, http.StatusBadRequest, passwordResponse{Error: "new password rejected: " + err.Error()})
+			return
+		}
+
+		persisted, err := config.RotatePassword(body.Password)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, passwordResponse{Error: err.Error()})
+			return
+		}
+		log.Printf("⚙ OMS password rotated for empNo=%s (persisted=%v)", next.EmpNo, persisted)
+		writeJSON(w, http.StatusOK, passwordResponse{OK: true, Persisted: persisted})
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+	"oms-automtion/utils"
+)
+
+// Decision is what a rule set does with one outage.
+type Decision struct {
+	Bucket   string `json:"bucket"` // label of the deciding rule
+	Action   string `json:"action"` // models.RuleSubmit, RuleSkip or RuleManualReview
+	ReasonID int    `json:"reason_id,omitempty"`
+}
+
+// DecisionChange is an outage the candidate rules decide differently from
+// the active ones.
+type DecisionChange struct {
+	OutageID  string   `json:"outage_id"`
+	Feeder    string   `json:"feeder"`
+	Hours     float64  `json:"hours"`
+	Active    Decision `json:"active"`
+	Candidate Decision `json:"candidate"`
+}
+
+// SimulationTotals summarises the decisions of one rule set.
+type SimulationTotals struct {
+	Submit       int     `json:"submit"`
+	Skipped      int     `json:"skipped"`
+	ManualReview int     `json:"manual_review"`
+	SkipRate     float64 `json:"skip_rate"` // Skipped / classified outages
+	ByReason     []Count `json:"by_reason"`
+	ByBucket     []Count `json:"by_bucket"`
+}
+
+// Count is one line of a per-reason or per-bucket breakdown.
+type Count struct {
+	Key   string `json:"key"` // reason ID or bucket label
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// SimulationReport is the outcome of replaying a candidate rule set over
+// stored outages next to the active rules.
+type SimulationReport struct {
+	Source          string           `json:"source"` // snapshot file, "history (N runs)" or "request"
+	Outages         int              `json:"outages"`
+	ParseErrors     int              `json:"parse_errors"` // outages without a usable duration
+	ActiveSource    string           `json:"active_source"`
+	CandidateSource string           `json:"candidate_source"`
+	Active          SimulationTotals `json:"active"`
+	Candidate       SimulationTotals `json:"candidate"`
+	Changes         []DecisionChange `json:"changes"`
+}
+
+// Simulate classifies every outage with both rule sets, without touching
+// OMS.
+func Simulate(outages []models.Outage, active, candidate []models.DurationRule) *SimulationReport {
+	rep := &SimulationReport{Outages: len(outages), Changes: []DecisionChange{}}
+	var act, cand tally
+	for _, o := range outages {
+		hours, err := utils.CalculateDurationFromTimestamps(
+			o.OutageOccurDate, o.OutageOccurTime,
+			o.OutageRestoreDate, o.OutageRestoreTime,
+		)
+		if err != nil {
+			rep.ParseErrors++
+			continue
+		}
+		a := decide(o, hours, active)
+		c := decide(o, hours, candidate)
+		act.add(a)
+		cand.add(c)
+		if a != c {
+			rep.Changes = append(rep.Changes, DecisionChange{
+				OutageID: o.ID, Feeder: o.FeederName, Hours: hours, Active: a, Candidate: c,
+			})
+		}
+	}
+	rep.Active, rep.Candidate = act.totals(), cand.totals()
+	return rep
+}
+
+func decide(o models.Outage, hours float64, rules []models.DurationRule) Decision {
+	r := utils.ClassifyRule(o, hours, rules)
+	d := Decision{Bucket: r.Label, Action: utils.RuleAction(r)}
+	if d.Action == models.RuleSubmit {
+		d.ReasonID = r.ReasonID
+	}
+	return d
+}
+
+type tally struct {
+	t        SimulationTotals
+	n        int
+	reasons  map[int]int
+	buckets  map[string]int
+	bucketOf []string // bucket labels in first-seen order
+}
+
+func (t *tally) add(d Decision) {
+	if t.reasons == nil {
+		t.reasons, t.buckets = map[int]int{}, map[string]int{}
+	}
+	t.n++
+	switch d.Action {
+	case models.RuleSubmit:
+		t.t.Submit++
+		t.reasons[d.ReasonID]++
+	case models.RuleSkip:
+		t.t.Skipped++
+	case models.RuleManualReview:
+		t.t.ManualReview++
+	}
+	if _, ok := t.buckets[d.Bucket]; !ok {
+		t.bucketOf = append(t.bucketOf, d.Bucket)
+	}
+	t.buckets[d.Bucket]++
+}
+
+func (t *tally) totals() SimulationTotals {
+	out := t.t
+	if t.n > 0 {
+		out.SkipRate = float64(out.Skipped) / float64(t.n)
+	}
+	out.ByReason, out.ByBucket = []Count{}, []Count{}
+	ids := slices.SortedFunc(maps.Keys(t.reasons), func(a, b int) int {
+		return cmp.Or(cmp.Compare(t.reasons[b], t.reasons[a]), cmp.Compare(a, b))
+	})
+	for _, id := range ids {
+		out.ByReason = append(out.ByReason, Count{Key: fmt.Sprint(id), Name: config.ReasonName(id), Count: t.reasons[id]})
+	}
+	for _, b := range t.bucketOf {
+		out.ByBucket = append(out.ByBucket, Count{Key: b, Count: t.buckets[b]})
+	}
+	return out
+}
+
+// readSnapshot reads stored outages: a JSON array of outages, or a pending
+// list response as OMS sends it ({"data": [...]}).
+func readSnapshot(path string) ([]models.Outage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	var outages []models.Outage
+	if err := json.Unmarshal(data, &outages); err == nil {
+		return outages, nil
+	}
+	var resp models.PendingResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("snapshot %s: want a JSON array of outages or {\"data\": [...]}: %w", path, err)
+	}
+	return resp.Data, nil
+}
+
+// runRulesCommand implements `rules`:
+//
+//	oms-automtion rules lint [-rules rules.json | rules.json] [-json]
+//	oms-automtion rules simulate -rules candidate.json [-snapshot outages.json | -since 2026-01-01 -until 2026-01-31] [-json]
+//
+// lint checks a rule set for overlaps, gaps and unreachable rules (see
+// config.LintRules). simulate replays the candidate rules over stored outages — a snapshot file
+// or the active profile's run history — and reports how their decisions differ from the
+// active rules (OMS_RULES_FILE, or the built-in ones).
+func runRulesCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("rules: want a subcommand: lint or simulate")
+	}
+	switch args[0] {
+	case "lint":
+		return rulesLint(args[1:])
+	case "simulate":
+		return rulesSimulate(args[1:])
+	}
+	return fmt.Errorf("rules: unknown subcommand %q (want lint or simulate)", args[0])
+}
+
+func rulesSimulate(args []string) error {
+	fs := flag.NewFlagSet("rules simulate", flag.ExitOnError)
+	candidatePath := fs.String("rules", "", "Candidate rules file (required)")
+	fs.StringVar(&rulesPath, "active", rulesPath, "Rules to compare against (default: OMS_RULES_FILE or the built-in rules)")
+	snapshot := fs.String("snapshot", "", "JSON file of outages to replay (default: the run history)")
+	historyPath := fs.String("history", config.HistoryPath(), "Run history file")
+	since := fs.String("since", "", "Only replay runs started on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "Only replay runs started on or before this date (YYYY-MM-DD)")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	if *candidatePath == "" {
+		return fmt.Errorf("rules simulate: -rules is required")
+	}
+	if err := loadConfig(config.ProfileName()); err != nil {
+		return err
+	}
+	candidate, err := config.ReadRules(*candidatePath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		outages []models.Outage
+		source  string
+	)
+	if *snapshot != "" {
+		if outages, err = readSnapshot(*snapshot); err != nil {
+			return err
+		}
+		source = *snapshot
+	} else {
+		from, to, err := historyRange(*since, *until)
+		if err != nil {
+			return fmt.Errorf("rules simulate: -%w", err)
+		}
+		entries, err := readHistory(*historyPath, config.ActiveProfile().Name, from, to)
+		if err != nil {
+			return err
+		}
+		outages = historyOutages(entries)
+		source = fmt.Sprintf("history (%d runs)", len(entries))
+	}
+
+	status := config.CurrentRulesStatus()
+	rep := Simulate(outages, status.Rules, candidate)
+	rep.Source, rep.ActiveSource, rep.CandidateSource = source, status.Source, *candidatePath
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	printSimulation(os.Stdout, rep)
+	return nil
+}
+
+// printSimulation writes the report as the CLI shows it.
+func printSimulation(out io.Writer, rep *SimulationReport) {
+	a, c := rep.Active, rep.Candidate
+	fmt.Fprintf(out, "═══ Rules simulation: %d outages from %s ═══\n", rep.Outages, rep.Source)
+	fmt.Fprintf(out, "  Active:    %s\n", rep.ActiveSource)
+	fmt.Fprintf(out, "  Candidate: %s\n", rep.CandidateSource)
+	if rep.ParseErrors > 0 {
+		fmt.Fprintf(out, "  [WARN] %d outages without a usable duration were left out\n", rep.ParseErrors)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %-28s %10s %10s\n", "", "active", "candidate")
+	fmt.Fprintf(out, "  %-28s %10d %10d\n", "submit", a.Submit, c.Submit)
+	fmt.Fprintf(out, "  %-28s %10d %10d\n", "skip", a.Skipped, c.Skipped)
+	fmt.Fprintf(out, "  %-28s %10d %10d\n", "manual review", a.ManualReview, c.ManualReview)
+	fmt.Fprintf(out, "  %-28s %9.1f%% %9.1f%%\n", "skip rate", 100*a.SkipRate, 100*c.SkipRate)
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "─── By reason ───")
+	for _, row := range mergeCounts(a.ByReason, c.ByReason) {
+		fmt.Fprintf(out, "  %-28s %10d %10d\n", truncate(row.key, 28), row.active, row.candidate)
+	}
+	fmt.Fprintln(out, "─── By bucket ───")
+	for _, row := range mergeCounts(a.ByBucket, c.ByBucket) {
+		fmt.Fprintf(out, "  %-28s %10d %10d\n", truncate(row.key, 28), row.active, row.candidate)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "─── Changed decisions: %d ───\n", len(rep.Changes))
+	for _, ch := range rep.Changes {
+		fmt.Fprintf(out, "  %-14s %-16s %6.2fh  %s → %s\n",
+			ch.OutageID, truncate(ch.Feeder, 16), ch.Hours, describeDecision(ch.Active), describeDecision(ch.Candidate))
+	}
+}
+
+type countRow struct {
+	key               string
+	active, candidate int
+}
+
+// mergeCounts lines up two breakdowns by key, active keys first.
+func mergeCounts(active, candidate []Count) []countRow {
+	var rows []countRow
+	index := map[string]int{}
+	label := func(c Count) string {
+		if c.Name != "" {
+			return c.Key + " " + c.Name
+		}
+		return c.Key
+	}
+	for _, c := range active {
+		index[label(c)] = len(rows)
+		rows = append(rows, countRow{key: label(c), active: c.Count})
+	}
+	for _, c := range candidate {
+		if i, ok := index[label(c)]; ok {
+			rows[i].candidate = c.Count
+			continue
+		}
+		rows = append(rows, countRow{key: label(c), candidate: c.Count})
+	}
+	return rows
+}
+
+func describeDecision(d Decision) string {
+	if d.Action == models.RuleSubmit {
+		return fmt.Sprintf("%s (reason %d)", d.Bucket, d.ReasonID)
+	}
+	return fmt.Sprintf("%s (%s)", d.Bucket, d.Action)
+}
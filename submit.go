@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"oms-automtion/config"
+	"oms-automtion/models"
+	"oms-automtion/oms"
+)
+
+// pairFlags collects repeated -pair LOC:REASON arguments.
+type pairFlags []models.ReasonPayloadItem
+
+func (p *pairFlags) String() string {
+	parts := make([]string, len(*p))
+	for i, it := range *p {
+		parts[i] = fmt.Sprintf("%d:%d", it.LocID, it.ReasonID)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p *pairFlags) Set(v string) error {
+	loc, reason, ok := strings.Cut(v, ":")
+	locID, err1 := strconv.Atoi(strings.TrimSpace(loc))
+	reasonID, err2 := strconv.Atoi(strings.TrimSpace(reason))
+	if !ok || err1 != nil || err2 != nil {
+		return fmt.Errorf("want LOC_ID:REASON_ID, got %q", v)
+	}
+	*p = append(*p, models.ReasonPayloadItem{LocID: locID, ReasonID: reasonID})
+	return nil
+}
+
+// runSubmitCommand implements `submit`: it records one or more
+// loc_id/reason_id pairs for a single outage by hand, e.g.
+//
+//	oms-automtion submit -outage OUT-1001 -reason 21 -loc 500101,500102
+//	oms-automtion submit -outage OUT-1001 -pair 500101:21 -pair 500102:20
+//
+// Every loc_id is checked against the feeder's HT poles before anything is
+// sent. Without -feeder-id the outage is looked up in the pending list.
+func runSubmitCommand(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	profile := fs.String("profile", config.ProfileName(), "OMS endpoint profile: production, training or local")
+	outageID := fs.String("outage", "", "Outage ID (required)")
+	feederID := fs.Int("feeder-id", 0, "Feeder ID of the outage (looked up in the pending list if 0)")
+	reasonID := fs.Int("reason", 0, "Reason ID to submit on every -loc pole")
+	locs := fs.String("loc", "", "Comma-separated loc_ids for -reason")
+	dryRun := fs.Bool("dry-run", false, "Validate the pairs but don't submit")
+	var pairs pairFlags
+	fs.Var(&pairs, "pair", "LOC_ID:REASON_ID pair to submit (repeatable)")
+	fs.Parse(args)
+
+	if *outageID == "" {
+		return fmt.Errorf("submit: -outage is required")
+	}
+	if *locs != "" {
+		if *reasonID == 0 {
+			return fmt.Errorf("submit: -loc needs -reason")
+		}
+		for _, s := range strings.Split(*locs, ",") {
+			if err := pairs.Set(strings.TrimSpace(s) + ":" + strconv.Itoa(*reasonID)); err != nil {
+				return fmt.Errorf("submit: -loc: %w", err)
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return fmt.Errorf("submit: give -pair LOC:REASON or -reason with -loc")
+	}
+	if err := loadConfig(*profile); err != nil {
+		return err
+	}
+	if err := loadCredentials(); err != nil {
+		return err
+	}
+	for _, it := range pairs {
+		if _, ok := config.LookupReason(it.ReasonID); !ok {
+			return fmt.Errorf("submit: unknown reason_id %d", it.ReasonID)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := newOMSClient()
+	if err != nil {
+		return err
+	}
+	if err := client.Login(ctx); err != nil {
+		return err
+	}
+
+	if *feederID == 0 {
+		if *feederID, err = findFeederID(ctx, client, *outageID); err != nil {
+			return err
+		}
+	}
+
+	detail, err := client.FetchOutageDetail(ctx, *outageID, *feederID)
+	if err != nil {
+		return err
+	}
+	poles := detail.PoleIDs
+	if err := oms.ValidatePayload(pairs, poles); err != nil {
+		if len(poles) == 0 {
+			return fmt.Errorf("submit %s: %v (%s)", *outageID, err, oms.DescribeTopology(detail.Topology))
+		}
+		return fmt.Errorf("submit %s: %w", *outageID, err)
+	}
+
+	for _, it := range pairs {
+		log.Printf("  → loc_id=%d reason_id=%d (%s)", it.LocID, it.ReasonID, config.ReasonName(it.ReasonID))
+	}
+	if *dryRun {
+		log.Printf("  ✓ %d pair(s) valid for %s — dry run, nothing submitted", len(pairs), *outageID)
+		return nil
+	}
+	if err := client.SubmitReasons(ctx, *outageID, pairs); err != nil {
+		return err
+	}
+	log.Printf("  ✓ Submitted %d pair(s) for %s", len(pairs), *outageID)
+	return nil
+}
+
+// findFeederID scans the pending list for outageID and returns its feeder.
+func findFeederID(ctx context.Context, api oms.API, outageID string) (int, error) {
+	for o, err := range api.PendingOutages(ctx, models.PendingFilter{}) {
+		if err != nil {
+			return 0, err
+		}
+		if o.ID == outageID {
+			return o.FeederID, nil
+		}
+	}
+	return 0, fmt.Errorf("outage %s is not pending; pass -feeder-id", outageID)
+}
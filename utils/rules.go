@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"oms-automtion/models"
+)
+
+// NoRule is what ClassifyRule returns for an outage no rule covers.
+var NoRule = models.DurationRule{Label: "no matching rule", Action: models.RuleSkip}
+
+// ClassifyRule decides what happens to an outage of the given duration: it
+// returns the highest-priority rule whose hours and conditions match, the
+// first one among equals, or NoRule.
+func ClassifyRule(o models.Outage, hours float64, rules []models.DurationRule) models.DurationRule {
+	best := -1
+	for i, r := range rules {
+		if best >= 0 && r.Priority <= rules[best].Priority {
+			continue
+		}
+		if RuleCovers(r, hours) && MatchRule(r.When, o) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return NoRule
+	}
+	return rules[best]
+}
+
+// RuleAction returns r's action, defaulting to models.RuleSubmit.
+func RuleAction(r models.DurationRule) string {
+	if r.Action == "" {
+		return models.RuleSubmit
+	}
+	return r.Action
+}
+
+// RuleCovers reports whether hours falls in r's duration range.
+func RuleCovers(r models.DurationRule, hours float64) bool {
+	if r.Exact != nil {
+		return math.Abs(hours-*r.Exact) <= r.Tolerance+exactEpsilon
+	}
+	switch {
+	case r.MinInclusive && hours < r.MinHours,
+		!r.MinInclusive && hours <= r.MinHours && r.MinHours > 0:
+		return false
+	}
+	switch {
+	case r.MaxHours == 0:
+		return true
+	case r.MaxExclusive:
+		return hours < r.MaxHours
+	default:
+		return hours <= r.MaxHours
+	}
+}
+
+// exactEpsilon absorbs float rounding at the edges of an exact match, so
+// 15.73 ± 0.01 includes 15.74.
+const exactEpsilon = 1e-9
+
+// DescribeRange renders r's duration range, e.g. "(3h, 8h]" or "15.73h ±0.01h".
+func DescribeRange(r models.DurationRule) string {
+	if r.Exact != nil {
+		if r.Tolerance == 0 {
+			return fmt.Sprintf("%gh", *r.Exact)
+		}
+		return fmt.Sprintf("%gh ±%gh", *r.Exact, r.Tolerance)
+	}
+	lo, hi := "(", "]"
+	if r.MinInclusive || r.MinHours == 0 {
+		lo = "["
+	}
+	if r.MaxExclusive {
+		hi = ")"
+	}
+	max := "∞"
+	if r.MaxHours > 0 {
+		max = fmt.Sprintf("%gh", r.MaxHours)
+	} else {
+		hi = ")"
+	}
+	return fmt.Sprintf("%s%gh, %s%s", lo, r.MinHours, max, hi)
+}
+
+// MatchRule reports whether o meets every condition of m. A nil m matches
+// every outage; time conditions never match an outage without a valid
+// occurrence time.
+func MatchRule(m *models.RuleMatch, o models.Outage) bool {
+	if m == nil {
+		return true
+	}
+	if !matchFeeder(m.Feeder, o.FeederName) ||
+		!matchText(m.FeederCategory, o.FeederCategory) ||
+		!matchText(m.InterruptionType, o.InterruptionType) ||
+		!matchText(m.Substation, o.SSName) ||
+		!matchText(m.Circle, o.DiscomCircleName) ||
+		!matchText(m.Division, o.DiscomDivisionName) ||
+		!matchText(m.Subdivision, o.SubdivisionName) {
+		return false
+	}
+	if len(m.OutageType) > 0 &&
+		!matchText(m.OutageType, strconv.Itoa(o.OutageType)) && !matchText(m.OutageType, o.OutageTypeName) {
+		return false
+	}
+
+	if len(m.Hour) == 0 && len(m.Weekday) == 0 && len(m.Month) == 0 {
+		return true
+	}
+	at, err := ParseTimestamp(o.OutageOccurDate, o.OutageOccurTime)
+	if err != nil {
+		return false
+	}
+	if len(m.Hour) > 0 && !slices.Contains(m.Hour, at.Hour()) {
+		return false
+	}
+	if len(m.Month) > 0 && !slices.Contains(m.Month, int(at.Month())) {
+		return false
+	}
+	if len(m.Weekday) > 0 && !slices.ContainsFunc(m.Weekday, func(s string) bool {
+		d, ok := ParseWeekday(s)
+		return ok && d == at.Weekday()
+	}) {
+		return false
+	}
+	return true
+}
+
+// ParseWeekday parses a day name, full or abbreviated to three letters
+// ("mon", "Monday"), ignoring case.
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// matchText reports whether got equals one of want, ignoring case and
+// surrounding spaces. An empty want matches anything.
+func matchText(want []string, got string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	got = strings.TrimSpace(got)
+	return slices.ContainsFunc(want, func(w string) bool {
+		return strings.EqualFold(strings.TrimSpace(w), got)
+	})
+}
+
+// matchFeeder is matchText with * and ? wildcards.
+func matchFeeder(patterns []string, feeder string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	feeder = strings.ToUpper(strings.TrimSpace(feeder))
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := path.Match(strings.ToUpper(strings.TrimSpace(p)), feeder)
+		return ok
+	})
+}
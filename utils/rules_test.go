@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+
+	"oms-automtion/models"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestRuleCovers(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  models.DurationRule
+		hours float64
+		want  bool
+	}{
+		{"open min excludes its edge", models.DurationRule{MinHours: 1, MaxHours: 3}, 1, false},
+		{"open min just above", models.DurationRule{MinHours: 1, MaxHours: 3}, 1.0001, true},
+		{"inclusive min includes its edge", models.DurationRule{MinHours: 1, MinInclusive: true, MaxHours: 3}, 1, true},
+		{"zero min includes zero", models.DurationRule{MaxHours: 0.25}, 0, true},
+		{"max includes its edge", models.DurationRule{MinHours: 1, MaxHours: 3}, 3, true},
+		{"exclusive max excludes its edge", models.DurationRule{MinHours: 1, MaxHours: 3, MaxExclusive: true}, 3, false},
+		{"no max is unbounded", models.DurationRule{MinHours: 8}, 1000, true},
+		{"exact hit", models.DurationRule{Exact: ptr(15.73)}, 15.73, true},
+		{"exact miss without tolerance", models.DurationRule{Exact: ptr(15.73)}, 15.74, false},
+		{"exact + tolerance edge", models.DurationRule{Exact: ptr(15.73), Tolerance: 0.01}, 15.74, true},
+		{"exact - tolerance edge", models.DurationRule{Exact: ptr(15.73), Tolerance: 0.01}, 15.72, true},
+		{"exact outside tolerance", models.DurationRule{Exact: ptr(15.73), Tolerance: 0.01}, 15.7401, false},
+		{"exact ignores min/max", models.DurationRule{Exact: ptr(2), MinHours: 5}, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RuleCovers(tt.rule, tt.hours); got != tt.want {
+				t.Errorf("RuleCovers(%s, %g) = %v, want %v", DescribeRange(tt.rule), tt.hours, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyRule(t *testing.T) {
+	rules := []models.DurationRule{
+		{Label: "short", MaxHours: 1, ReasonID: 21},
+		{Label: "short too", MaxHours: 2, ReasonID: 20},
+		{Label: "long", MinHours: 2, MaxHours: 8, ReasonID: 9},
+		{Label: "K", MinHours: 6, ReasonID: 25, Priority: 10, When: &models.RuleMatch{Feeder: []string{"KUMBHIYA"}}},
+		{Label: "K any", ReasonID: 31, Priority: 10, When: &models.RuleMatch{Feeder: []string{"KUM*"}}},
+		{Label: "very long", MinHours: 10, Action: models.RuleSkip, Priority: -1},
+	}
+	tests := []struct {
+		name   string
+		feeder string
+		hours  float64
+		want   string
+	}{
+		{"first among equal priority", "A", 0.5, "short"},
+		{"later rule when the first doesn't cover", "A", 1.5, "short too"},
+		{"higher priority beats earlier rule", "KUMBHIYA", 7, "K"},
+		{"priority tie goes to the first", "KUMBHIYA", 9, "K"},
+		{"pattern rule", "KUMBHAR", 0.5, "K any"},
+		{"negative priority still applies", "A", 11, "very long"},
+		{"no rule covers", "A", 9, NoRule.Label},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyRule(models.Outage{FeederName: tt.feeder}, tt.hours, rules)
+			if got.Label != tt.want {
+				t.Errorf("ClassifyRule(%s, %gh) = %q, want %q", tt.feeder, tt.hours, got.Label, tt.want)
+			}
+		})
+	}
+}
@@ -1,45 +1,31 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
-
-	"oms-automtion/models"
 )
 
 // CalculateDurationFromTimestamps calculates duration in hours
 // from separate date and time strings (e.g. "2026-01-28" + "17:37:25.743")
 func CalculateDurationFromTimestamps(occurDate, occurTime, restoreDate, restoreTime string) (float64, error) {
-	occurDate = strings.TrimSpace(occurDate)
-	occurTime = strings.TrimSpace(occurTime)
-	restoreDate = strings.TrimSpace(restoreDate)
-	restoreTime = strings.TrimSpace(restoreTime)
-
-	if occurDate == "" || occurTime == "" {
+	if strings.TrimSpace(occurDate) == "" || strings.TrimSpace(occurTime) == "" {
 		return 0, fmt.Errorf("outage occur date/time is empty")
 	}
-
-	// Strip sub-second precision for simpler parsing
-	occurTimeClean := strings.Split(occurTime, ".")[0]
-	occurStr := occurDate + " " + occurTimeClean
-
-	const layout = "2006-01-02 15:04:05"
-	occurParsed, err := time.Parse(layout, occurStr)
+	occurParsed, err := ParseTimestamp(occurDate, occurTime)
 	if err != nil {
-		return 0, fmt.Errorf("parse occur %q: %w", occurStr, err)
+		return 0, fmt.Errorf("parse occur: %w", err)
 	}
 
 	var restoreParsed time.Time
-	if restoreDate == "" || restoreTime == "" {
+	if strings.TrimSpace(restoreDate) == "" || strings.TrimSpace(restoreTime) == "" {
 		restoreParsed = time.Now()
 	} else {
-		restoreTimeClean := strings.Split(restoreTime, ".")[0]
-		restoreStr := restoreDate + " " + restoreTimeClean
-		restoreParsed, err = time.Parse(layout, restoreStr)
+		restoreParsed, err = ParseTimestamp(restoreDate, restoreTime)
 		if err != nil {
-			return 0, fmt.Errorf("parse restore %q: %w", restoreStr, err)
+			return 0, fmt.Errorf("parse restore: %w", err)
 		}
 	}
 
@@ -51,6 +37,21 @@ func CalculateDurationFromTimestamps(occurDate, occurTime, restoreDate, restoreT
 	return dur.Hours(), nil
 }
 
+// ParseTimestamp parses an OMS date and time pair such as "2026-01-28" +
+// "17:37:25.743". Sub-second precision is dropped.
+func ParseTimestamp(date, clock string) (time.Time, error) {
+	// Strip sub-second precision for simpler parsing
+	clock = strings.Split(strings.TrimSpace(clock), ".")[0]
+	str := strings.TrimSpace(date) + " " + clock
+
+	const layout = "2006-01-02 15:04:05"
+	t, err := time.Parse(layout, str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q: %w", str, err)
+	}
+	return t, nil
+}
+
 // ParseDuration parses duration string "HH:MM:SS" or "HH:MM:SS.mmm"
 // into total hours (supports > 24 hours)
 func ParseDuration(raw string) (float64, error) {
@@ -95,18 +96,15 @@ func ParseDuration(raw string) (float64, error) {
 	return totalHours, nil
 }
 
-// ClassifyRule returns the first matching rule based on MaxHours
-func ClassifyRule(hours float64, rules []models.DurationRule) models.DurationRule {
-	for _, r := range rules {
-		if hours <= r.MaxHours {
-			return r
-		}
-	}
-
-	// Return last rule if nothing matched
-	if len(rules) > 0 {
-		return rules[len(rules)-1]
+// SleepContext pauses for d or until ctx is done, whichever comes first.
+// It returns ctx.Err() if the wait was cut short.
+func SleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
-
-	return models.DurationRule{}
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oms-automtion/models"
+	"oms-automtion/oms"
+	"oms-automtion/utils"
+)
+
+// Verification modes for RunOptions.Verify.
+const (
+	VerifyOff     = ""        // trust a 200 from submit
+	VerifyPending = "pending" // the outage must drop off /reason/pending
+	VerifyDetail  = "detail"  // the detail must show the submitted reason (pending list if it shows none)
+)
+
+// Verification outcomes recorded in ProcessedRow.Verification.
+const (
+	Verified     = "verified"
+	Unverified   = "unverified"    // the check itself failed, or OMS shows something else
+	StillPending = "still_pending" // OMS accepted the submit but didn't record it
+)
+
+// verifyDelays are the waits before each verification check. OMS may take a
+// moment to update, so a negative answer is only trusted after the last one.
+var verifyDelays = []time.Duration{0, 500 * time.Millisecond, 2 * time.Second}
+
+// ParseVerifyMode checks a -verify / OMS_VERIFY / ?verify= value.
+func ParseVerifyMode(s string) (string, error) {
+	switch s {
+	case VerifyOff, "off", "none":
+		return VerifyOff, nil
+	case VerifyPending, VerifyDetail:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown verify mode %q (want pending or detail)", s)
+}
+
+// verifySubmit re-queries OMS after a successful submit of items for o and
+// returns one of Verified, Unverified or StillPending with a short note.
+func verifySubmit(ctx context.Context, api oms.API, mode string, o models.Outage, items []models.ReasonPayloadItem) (string, string) {
+	var (
+		status, note string
+		err          error
+	)
+	for _, d := range verifyDelays {
+		if err := utils.SleepContext(ctx, d); err != nil {
+			return Unverified, "verify: " + err.Error()
+		}
+		switch mode {
+		case VerifyPending:
+			if status, note, err = verifyNotPending(ctx, api, o); err != nil {
+				err = fmt.Errorf("%w; verify by detail instead", err)
+			}
+		case VerifyDetail:
+			status, note, err = verifyDetail(ctx, api, o, items)
+		default:
+			return "", ""
+		}
+		if err != nil {
+			return Unverified, "verify: " + err.Error()
+		}
+		if status == Verified {
+			return status, note
+		}
+	}
+	return status, note
+}
+
+// verifyPendingMax caps how many of a feeder's pending outages
+// verifyNotPending reads, so verifying a busy feeder doesn't page through its
+// whole list at the rate limit after every submit.
+const verifyPendingMax = 50
+
+// verifyNotPending confirms o no longer appears on the pending list of its
+// feeder. A feeder with more than verifyPendingMax pending outages can't be
+// checked this way and is reported as an error.
+func verifyNotPending(ctx context.Context, api oms.API, o models.Outage) (string, string, error) {
+	n := 0
+	for p, err := range api.PendingOutages(ctx, models.PendingFilter{Feeder: o.FeederName}) {
+		if err != nil {
+			return "", "", err
+		}
+		if p.ID == o.ID {
+			return StillPending, "still on the pending list after submit", nil
+		}
+		if n++; n >= verifyPendingMax {
+			return "", "", fmt.Errorf("feeder %s has over %d pending outages", o.FeederName, verifyPendingMax)
+		}
+	}
+	return Verified, "", nil
+}
+
+// verifyDetail confirms the outage detail records the first submitted pair.
+//
+// No captured OMS detail response shows outageData carrying reason_id after a
+// submit; the key is what the fake OMS serves. So a detail without a reason
+// proves nothing either way, and the pending list decides instead.
+func verifyDetail(ctx context.Context, api oms.API, o models.Outage, items []models.ReasonPayloadItem) (string, string, error) {
+	detail, err := api.FetchOutageDetail(ctx, o.ID, o.FeederID)
+	if err != nil {
+		return "", "", err
+	}
+	want := items[0]
+	switch got := detail.Data.ReasonID; {
+	case got == 0:
+		status, note, err := verifyNotPending(ctx, api, o)
+		if err != nil {
+			return "", "", fmt.Errorf("detail shows no reason_id and the pending list can't tell: %w", err)
+		}
+		if status == Verified {
+			note = "detail shows no reason_id; off the pending list"
+		}
+		return status, note, nil
+	case got != want.ReasonID:
+		return Unverified, fmt.Sprintf("detail shows reason_id=%d, submitted %d", got, want.ReasonID), nil
+	}
+	return Verified, "", nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"oms-automtion/models"
+	"oms-automtion/oms"
+)
+
+func TestVerifyNotPending(t *testing.T) {
+	withPending := func(n int) *oms.Memory {
+		m := oms.NewMemory()
+		for i := range n {
+			m.AddOutage(models.Outage{ID: fmt.Sprint(i), FeederName: "F"})
+		}
+		m.AddOutage(models.Outage{ID: "other", FeederName: "G"})
+		if err := m.Login(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	tests := []struct {
+		name    string
+		api     *oms.Memory
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"gone", withPending(10), "submitted", Verified, false},
+		{"still pending", withPending(10), "3", StillPending, false},
+		{"other feeder", withPending(0), "other", Verified, false},
+		{"found before cap", withPending(2 * verifyPendingMax), "5", StillPending, false},
+		{"busy feeder", withPending(2 * verifyPendingMax), "submitted", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := verifyNotPending(context.Background(), tt.api, models.Outage{ID: tt.id, FeederName: "F"})
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("verifyNotPending = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// noReasonDetail serves details without a reason_id, as an OMS whose
+// outageData doesn't carry the recorded reason would.
+type noReasonDetail struct{ *oms.Memory }
+
+func (m noReasonDetail) FetchOutageDetail(ctx context.Context, outageID string, feederID int) (*models.OutageDetail, error) {
+	d, err := m.Memory.FetchOutageDetail(ctx, outageID, feederID)
+	if d != nil {
+		d.Data.ReasonID, d.Data.LocID = 0, 0
+	}
+	return d, err
+}
+
+func TestVerifyDetail(t *testing.T) {
+	tests := []struct {
+		name     string
+		reason   int  // the reason_id verified; OMS records 21
+		submit   bool // OMS recorded the reason
+		noReason bool // detail carries no reason_id
+		want     string
+		wantNote string
+	}{
+		{"recorded", 21, true, false, Verified, ""},
+		{"other reason", 20, true, false, Unverified, "detail shows reason_id=21, submitted 20"},
+		{"no reason_id, off pending", 21, true, true, Verified, "detail shows no reason_id; off the pending list"},
+		{"no reason_id, still pending", 21, false, true, StillPending, "still on the pending list after submit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := models.Outage{ID: "a", FeederID: 7, FeederName: "F"}
+			m := oms.NewMemory().AddOutage(o, 1)
+			ctx := context.Background()
+			if err := m.Login(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if tt.submit {
+				if err := m.SubmitReasons(ctx, o.ID, []models.ReasonPayloadItem{{LocID: 1, ReasonID: 21}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var api oms.API = m
+			if tt.noReason {
+				api = noReasonDetail{m}
+			}
+			items := []models.ReasonPayloadItem{{LocID: 1, ReasonID: tt.reason}}
+			got, note, err := verifyDetail(ctx, api, o, items)
+			if err != nil || got != tt.want || note != tt.wantNote {
+				t.Errorf("verifyDetail = %q, %q, %v; want %q, %q", got, note, err, tt.want, tt.wantNote)
+			}
+		})
+	}
+}